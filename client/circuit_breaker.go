@@ -16,39 +16,50 @@ package client
 
 import (
 	"strings"
+	"sync"
 
 	kitexclient "github.com/cloudwego/kitex/client"
 	"github.com/cloudwego/kitex/pkg/circuitbreak"
 	"github.com/cloudwego/kitex/pkg/rpcinfo"
 	"github.com/kitex-contrib/config-file/monitor"
+	"github.com/kitex-contrib/config-file/parser"
 	"github.com/kitex-contrib/config-file/utils"
 )
 
 // WithCircuitBreaker returns a server.Option that sets the circuit breaker for the client
 func WithCircuitBreaker(service string, watcher monitor.ConfigMonitor) []kitexclient.Option {
-	cbSuite, keyCircuitBreaker := initCircuitBreaker(service, watcher)
+	return withCircuitBreaker(service, watcher, nil)
+}
+
+func withCircuitBreaker(service string, watcher monitor.ConfigMonitor, methods *methodSet) []kitexclient.Option {
+	cbSuite, keyCircuitBreaker, removeListener := initCircuitBreaker(service, watcher, methods)
 	return []kitexclient.Option{
 		kitexclient.WithCircuitBreaker(cbSuite),
 		kitexclient.WithCloseCallbacks(func() error {
 			watcher.DeregisterCallback(keyCircuitBreaker)
+			removeListener()
 			return cbSuite.Close()
 		}),
 	}
 }
 
 // initCircuitBreaker init the circuitbreaker suite
-func initCircuitBreaker(service string, watcher monitor.ConfigMonitor) (*circuitbreak.CBSuite, int64) {
+func initCircuitBreaker(service string, watcher monitor.ConfigMonitor, methods *methodSet) (*circuitbreak.CBSuite, int64, func()) {
 	cb := circuitbreak.NewCBSuite(genServiceCBKeyWithRPCInfo)
 	lcb := utils.ThreadSafeSet{}
+	var lock sync.Mutex
 
 	onChangeCallback := func() {
+		lock.Lock()
+		defer lock.Unlock()
+
 		set := utils.Set{}
 		config := getFileConfig(watcher)
 		if config == nil {
 			return // config is nil, do nothing, log will be printed in getFileConfig
 		}
 
-		for method, config := range config.Circuitbreaker {
+		for method, config := range parser.ExpandMethods(config.Circuitbreaker, methods.list()) {
 			set[method] = true
 			key := genServiceCBKey(service, method)
 			cb.UpdateServiceCBConfig(key, *config)
@@ -60,8 +71,9 @@ func initCircuitBreaker(service string, watcher monitor.ConfigMonitor) (*circuit
 		}
 	}
 
-	keyCircuitBreaker := watcher.RegisterCallback(onChangeCallback)
-	return cb, keyCircuitBreaker
+	keyCircuitBreaker := watcher.RegisterCallbackFor("circuitbreaker", onChangeCallback)
+	removeListener := methods.onAdd(onChangeCallback)
+	return cb, keyCircuitBreaker, removeListener
 }
 
 func genServiceCBKeyWithRPCInfo(ri rpcinfo.RPCInfo) string {
@@ -0,0 +1,112 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+// methodSet is the set of methods of the service, against which the method patterns in the config are expanded.
+// It starts with the methods given by utils.Options.Methods and learns the others from the calls.
+type methodSet struct {
+	lock      sync.RWMutex
+	methods   map[string]bool
+	listeners map[int64]func()
+	counter   int64 // id of the last listener
+}
+
+func newMethodSet(methods []string) *methodSet {
+	s := &methodSet{methods: make(map[string]bool, len(methods)), listeners: make(map[int64]func())}
+	for _, method := range methods {
+		s.methods[method] = true
+	}
+	return s
+}
+
+// list returns the known methods in order, it is safe to call on a nil set.
+func (s *methodSet) list() []string {
+	if s == nil {
+		return nil
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	out := make([]string, 0, len(s.methods))
+	for method := range s.methods {
+		out = append(out, method)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// onAdd registers a listener called when a new method is learned and returns the function removing it,
+// which is called when the client is closed. It is safe to call on a nil set.
+func (s *methodSet) onAdd(listener func()) (remove func()) {
+	if s == nil {
+		return func() {}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.counter++
+	id := s.counter
+	s.listeners[id] = listener
+	return func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		delete(s.listeners, id)
+	}
+}
+
+// add adds the method and notifies the listeners if it is new.
+func (s *methodSet) add(method string) {
+	s.lock.RLock()
+	known := s.methods[method]
+	s.lock.RUnlock()
+	if known {
+		return
+	}
+
+	s.lock.Lock()
+	if s.methods[method] {
+		s.lock.Unlock()
+		return
+	}
+	s.methods[method] = true
+	listeners := make([]func(), 0, len(s.listeners))
+	for _, listener := range s.listeners {
+		listeners = append(listeners, listener)
+	}
+	s.lock.Unlock()
+
+	klog.Debugf("[local] client learned method %s, expand the method patterns\n", method)
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// middleware learns the methods from the calls, the policies of the patterns apply from the next call of a new method.
+func (s *methodSet) middleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		if ri := rpcinfo.GetRPCInfo(ctx); ri != nil && ri.To() != nil {
+			s.add(ri.To().Method())
+		}
+		return next(ctx, req, resp)
+	}
+}
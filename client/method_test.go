@@ -0,0 +1,37 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import "testing"
+
+func TestMethodSetListeners(t *testing.T) {
+	s := newMethodSet([]string{"Echo"})
+	var calls int
+	remove := s.onAdd(func() { calls++ })
+
+	s.add("Echo")
+	s.add("Pay")
+	if calls != 1 {
+		t.Errorf("the listener should be called once for the new method, got %d", calls)
+	}
+
+	// the listeners of a closed client are removed
+	remove()
+	s.add("Order")
+	if calls != 1 || len(s.listeners) != 0 {
+		t.Errorf("the removed listener should not be called, got %d calls and %d listeners", calls, len(s.listeners))
+	}
+	(*methodSet)(nil).onAdd(func() {})()
+}
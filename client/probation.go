@@ -0,0 +1,222 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/kitex-contrib/config-file/monitor"
+	"github.com/kitex-contrib/config-file/utils"
+)
+
+const (
+	defaultProbationSamples = 100
+	maxLatencySamples       = 1024 // latencies kept for the p99 of a revision
+)
+
+// probation puts each new config of the client on probation, comparing its calls with the ones of the last good config,
+// and rolls it back if the error rate or the p99 latency regresses beyond the policy.
+// The calls are recorded by atomic counters, the lock is only taken to judge the config every MinSamples calls.
+type probation struct {
+	watcher monitor.ConfigMonitor
+	policy  utils.Probation
+	current atomic.Pointer[callStats] // calls since the current config was applied
+
+	lock     sync.Mutex
+	baseline *callStats // calls of the last good config, set during probation
+	revision int64      // revision on probation, 0 if none
+	good     int64      // revision to roll back to
+	deadline time.Time  // end of the probation
+	rollback int64      // revision being rolled back to, which is not put on probation again
+}
+
+func newProbation(watcher monitor.ConfigMonitor, policy utils.Probation) *probation {
+	if policy.MinSamples <= 0 {
+		policy.MinSamples = defaultProbationSamples
+	}
+	p := &probation{watcher: watcher, policy: policy}
+	p.current.Store(&callStats{})
+	return p
+}
+
+// onChange starts the probation of the new config, it is registered by RegisterChangeCallback.
+func (p *probation) onChange(old, new interface{}, _ monitor.Diff) {
+	history := p.watcher.History()
+	if len(history) == 0 {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	prev := p.current.Swap(&callStats{})
+	if p.revision != 0 && time.Now().After(p.deadline) {
+		p.pass()
+	}
+	if p.rollback != 0 {
+		target := p.rollback
+		p.rollback = 0
+		for _, r := range history {
+			if r.ID == target && r.Config == new {
+				return // the good config is restored
+			}
+		}
+	}
+	if old == nil || len(history) < 2 {
+		return
+	}
+
+	revision := history[len(history)-1].ID
+	if p.revision == 0 {
+		// compared with the last good config, even if the new one replaces another config on probation
+		if calls := prev.calls.Load(); calls < int64(p.policy.MinSamples) {
+			klog.Infof("[local] %s client config revision %d is not on probation, the previous one has only %d calls",
+				p.watcher.Key(), revision, calls)
+			return
+		}
+		p.baseline, p.good = prev, history[len(history)-2].ID
+	}
+	p.revision, p.deadline = revision, time.Now().Add(p.policy.Window)
+	klog.Infof("[local] %s client config revision %d is on probation until %v", p.watcher.Key(), revision, p.deadline)
+}
+
+// middleware records the calls, and judges the config on probation every MinSamples calls.
+func (p *probation) middleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		start := time.Now()
+		err := next(ctx, req, resp)
+		p.record(time.Since(start), err != nil)
+		return err
+	}
+}
+
+func (p *probation) record(latency time.Duration, failed bool) {
+	current := p.current.Load()
+	if current.add(latency, failed)%int64(p.policy.MinSamples) == 0 {
+		p.judge(current)
+	}
+}
+
+// judge rolls back the config on probation if current, its calls, regress from the baseline.
+// The statistics are computed outside the lock, which only guards the state of the probation.
+func (p *probation) judge(current *callStats) {
+	p.lock.Lock()
+	revision, baseline, good := p.revision, p.baseline, p.good
+	if revision != 0 && time.Now().After(p.deadline) {
+		p.pass()
+		revision = 0
+	}
+	p.lock.Unlock()
+	if revision == 0 || p.current.Load() != current {
+		return
+	}
+
+	reason := p.regression(current, baseline, good)
+	if reason == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.revision != revision || p.current.Load() != current {
+		return // judged by another call, or replaced by a new config meanwhile
+	}
+	p.revision, p.baseline, p.rollback = 0, nil, good
+	// the rollback invokes the callbacks of the monitor, which must not block the call
+	go p.rollbackTo(revision, good, reason)
+}
+
+// pass ends the probation of the current revision, it must be called under lock.
+func (p *probation) pass() {
+	klog.Infof("[local] %s client config revision %d passed the probation", p.watcher.Key(), p.revision)
+	p.revision, p.baseline = 0, nil
+}
+
+// regression returns why the current calls regress from the baseline of revision good, nil if they do not.
+func (p *probation) regression(current, baseline *callStats, good int64) error {
+	if limit := p.policy.MaxErrorRateIncrease; limit > 0 {
+		if rate, base := current.errorRate(), baseline.errorRate(); rate-base > limit {
+			return fmt.Errorf("error rate %.4f exceeds %.4f of revision %d by more than %v", rate, base, good, limit)
+		}
+	}
+	if limit := p.policy.MaxLatencyRatio; limit > 0 {
+		if p99, base := current.p99(), baseline.p99(); base > 0 && float64(p99) > float64(base)*limit {
+			return fmt.Errorf("p99 latency %v exceeds %v of revision %d by more than %v times", p99, base, good, limit)
+		}
+	}
+	return nil
+}
+
+func (p *probation) rollbackTo(from, to int64, reason error) {
+	klog.Warnf("[local] %s client config revision %d regressed: %v, roll back to revision %d", p.watcher.Key(), from, reason, to)
+	if err := p.watcher.Rollback(to); err != nil {
+		klog.Errorf("[local] %s client config failed to roll back to revision %d: %v", p.watcher.Key(), to, err)
+		p.lock.Lock()
+		p.rollback = 0
+		p.lock.Unlock()
+		return
+	}
+	if p.policy.OnRollback != nil {
+		p.policy.OnRollback(from, to, reason)
+	}
+}
+
+// callStats is the statistics of the calls with a config, recorded without locking.
+type callStats struct {
+	calls     atomic.Int64
+	errors    atomic.Int64
+	latencies [maxLatencySamples]atomic.Int64 // the last maxLatencySamples latencies, as a ring indexed by the call
+}
+
+// add records a call and returns the number of the calls.
+func (s *callStats) add(latency time.Duration, failed bool) int64 {
+	if failed {
+		s.errors.Add(1)
+	}
+	n := s.calls.Add(1)
+	s.latencies[(n-1)%maxLatencySamples].Store(int64(latency))
+	return n
+}
+
+func (s *callStats) errorRate() float64 {
+	calls := s.calls.Load()
+	if calls == 0 {
+		return 0
+	}
+	return float64(s.errors.Load()) / float64(calls)
+}
+
+// p99 returns the p99 of the recorded latencies, a latency being recorded concurrently may be missed.
+func (s *callStats) p99() time.Duration {
+	n := s.calls.Load()
+	if n == 0 {
+		return 0
+	}
+	if n > maxLatencySamples {
+		n = maxLatencySamples
+	}
+	sorted := make([]time.Duration, n)
+	for i := range sorted {
+		sorted[i] = time.Duration(s.latencies[i].Load())
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)*99-1)/100]
+}
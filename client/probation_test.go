@@ -0,0 +1,166 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kitex-contrib/config-file/monitor"
+	"github.com/kitex-contrib/config-file/utils"
+)
+
+// historyMonitor is a monitor with a fixed history, which applies the rollbacks by invoking the change callback.
+type historyMonitor struct {
+	monitor.ConfigMonitor
+	history  []monitor.Revision
+	onChange monitor.ChangeCallback
+}
+
+func (m *historyMonitor) Key() string { return "Client" }
+
+func (m *historyMonitor) History() []monitor.Revision { return m.history }
+
+func (m *historyMonitor) apply(config interface{}) {
+	var old interface{}
+	if len(m.history) > 0 {
+		old = m.history[len(m.history)-1].Config
+	}
+	m.history = append(m.history, monitor.Revision{ID: int64(len(m.history) + 1), Config: config})
+	m.onChange(old, config, nil)
+}
+
+func (m *historyMonitor) Rollback(revision int64) error {
+	m.apply(m.history[revision-1].Config)
+	return nil
+}
+
+func TestProbation(t *testing.T) {
+	m := &historyMonitor{}
+	rolledBack := make(chan [2]int64, 1)
+	p := newProbation(m, utils.Probation{
+		Window:               time.Minute,
+		MinSamples:           10,
+		MaxErrorRateIncrease: 0.2,
+		OnRollback:           func(from, to int64, reason error) { rolledBack <- [2]int64{from, to} },
+	})
+	m.onChange = p.onChange
+
+	failing := errors.New("failed")
+	call := func(n int, err error) {
+		handler := p.middleware(func(ctx context.Context, req, resp interface{}) error { return err })
+		for i := 0; i < n; i++ {
+			_ = handler(context.Background(), nil, nil)
+		}
+	}
+
+	good, bad := &struct{ v int }{1}, &struct{ v int }{2}
+	m.apply(good)
+	call(10, nil)
+	m.apply(bad)
+	if p.revision != 2 || p.good != 1 {
+		t.Fatalf("revision 2 should be on probation, got revision %d good %d", p.revision, p.good)
+	}
+	call(10, failing)
+	select {
+	case got := <-rolledBack:
+		if got != [2]int64{2, 1} || m.history[2].Config != good {
+			t.Errorf("unexpected rollback %v, history %+v", got, m.history)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("revision 2 should be rolled back")
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.revision != 0 {
+		t.Errorf("the restored config should not be on probation, got revision %d", p.revision)
+	}
+}
+
+func TestProbationPassed(t *testing.T) {
+	m := &historyMonitor{}
+	p := newProbation(m, utils.Probation{Window: time.Minute, MinSamples: 10, MaxLatencyRatio: 2})
+	m.onChange = p.onChange
+
+	m.apply(&struct{}{})
+	m.apply(&struct{}{})
+	if p.revision != 0 {
+		t.Errorf("revision 2 should not be on probation without the calls of revision 1")
+	}
+
+	for i := 0; i < 10; i++ {
+		p.record(time.Millisecond, false)
+	}
+	m.apply(&struct{}{})
+	for i := 0; i < 10; i++ {
+		p.record(time.Millisecond, false)
+	}
+	if p.revision != 3 || p.regression(p.current.Load(), p.baseline, p.good) != nil {
+		t.Errorf("revision 3 should be on probation without regression")
+	}
+	p.deadline = time.Now()
+	for i := 0; i < 10; i++ {
+		p.record(time.Second, false)
+	}
+	if p.revision != 0 {
+		t.Errorf("revision 3 should pass the probation after the window")
+	}
+}
+
+func TestProbationConcurrent(t *testing.T) {
+	m := &historyMonitor{}
+	rollbacks := make(chan [2]int64, 10)
+	p := newProbation(m, utils.Probation{
+		Window:          time.Minute,
+		MinSamples:      10,
+		MaxLatencyRatio: 2,
+		OnRollback:      func(from, to int64, reason error) { rollbacks <- [2]int64{from, to} },
+	})
+	m.onChange = p.onChange
+
+	m.apply(&struct{}{})
+	for i := 0; i < 100; i++ {
+		p.record(time.Millisecond, false)
+	}
+	m.apply(&struct{}{})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				p.record(time.Second, false)
+			}
+		}()
+	}
+	wg.Wait()
+	select {
+	case got := <-rollbacks:
+		if got != [2]int64{2, 1} {
+			t.Errorf("unexpected rollback %v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("revision 2 should be rolled back")
+	}
+	select {
+	case got := <-rollbacks:
+		t.Errorf("revision 2 should be rolled back once, got another rollback %v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -15,38 +15,50 @@
 package client
 
 import (
+	"sync"
+
 	kitexclient "github.com/cloudwego/kitex/client"
 	"github.com/cloudwego/kitex/pkg/klog"
 	"github.com/cloudwego/kitex/pkg/retry"
 	"github.com/kitex-contrib/config-file/monitor"
+	"github.com/kitex-contrib/config-file/parser"
 	"github.com/kitex-contrib/config-file/utils"
 )
 
 // WithRetryPolicy returns a server.Option that sets the retry policies for the client
 func WithRetryPolicy(watcher monitor.ConfigMonitor) []kitexclient.Option {
-	rc, keyRetry := initRetryContainer(watcher)
+	return withRetryPolicy(watcher, nil)
+}
+
+func withRetryPolicy(watcher monitor.ConfigMonitor, methods *methodSet) []kitexclient.Option {
+	rc, keyRetry, removeListener := initRetryContainer(watcher, methods)
 	return []kitexclient.Option{
 		kitexclient.WithRetryContainer(rc),
 		kitexclient.WithCloseCallbacks(func() error {
 			watcher.DeregisterCallback(keyRetry)
+			removeListener()
 			return rc.Close()
 		}),
 	}
 }
 
 // initRetryOptions init the retry container
-func initRetryContainer(watcher monitor.ConfigMonitor) (*retry.Container, int64) {
+func initRetryContainer(watcher monitor.ConfigMonitor, methods *methodSet) (*retry.Container, int64, func()) {
 	retryContainer := retry.NewRetryContainerWithPercentageLimit()
 
 	ts := utils.ThreadSafeSet{}
+	var lock sync.Mutex
 
 	onChangeCallback := func() {
+		lock.Lock()
+		defer lock.Unlock()
+
 		// the key is method name, wildcard "*" can match anything.
 		config := getFileConfig(watcher)
 		if config == nil {
 			return // config is nil, do nothing, log will be printed in getFileConfig
 		}
-		rcs := config.Retry
+		rcs := parser.ExpandMethods(config.Retry, methods.list())
 		set := utils.Set{}
 
 		for method, policy := range rcs {
@@ -64,7 +76,8 @@ func initRetryContainer(watcher monitor.ConfigMonitor) (*retry.Container, int64)
 				continue
 			}
 
-			retryContainer.NotifyPolicyChange(method, *policy)
+			// kitex fills the defaults into the policy, which must not modify the shared config
+			retryContainer.NotifyPolicyChange(method, *policy.DeepCopy())
 		}
 
 		for _, method := range ts.DiffAndEmplace(set) {
@@ -72,7 +85,8 @@ func initRetryContainer(watcher monitor.ConfigMonitor) (*retry.Container, int64)
 		}
 	}
 
-	keyRetry := watcher.RegisterCallback(onChangeCallback)
+	keyRetry := watcher.RegisterCallbackFor("retry", onChangeCallback)
+	removeListener := methods.onAdd(onChangeCallback)
 
-	return retryContainer, keyRetry
+	return retryContainer, keyRetry, removeListener
 }
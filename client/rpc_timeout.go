@@ -15,37 +15,50 @@
 package client
 
 import (
+	"sync"
+
 	kitexclient "github.com/cloudwego/kitex/client"
 	"github.com/cloudwego/kitex/pkg/rpcinfo"
 	"github.com/cloudwego/kitex/pkg/rpctimeout"
 	"github.com/kitex-contrib/config-file/monitor"
+	"github.com/kitex-contrib/config-file/parser"
 )
 
 // WithRPCTimeout returns a server.Option that sets the timeout provider for the client.
 func WithRPCTimeout(watcher monitor.ConfigMonitor) []kitexclient.Option {
-	opt, keyRPCTimeout := initRPCTimeout(watcher)
+	return withRPCTimeout(watcher, nil)
+}
+
+func withRPCTimeout(watcher monitor.ConfigMonitor, methods *methodSet) []kitexclient.Option {
+	opt, keyRPCTimeout, removeListener := initRPCTimeout(watcher, methods)
 	return []kitexclient.Option{
 		kitexclient.WithTimeoutProvider(opt),
 		kitexclient.WithCloseCallbacks(func() error {
 			watcher.DeregisterCallback(keyRPCTimeout)
+			removeListener()
 			return nil
 		}),
 	}
 }
 
 // initRPCTimeout init the rpc timeout provider
-func initRPCTimeout(watcher monitor.ConfigMonitor) (rpcinfo.TimeoutProvider, int64) {
+func initRPCTimeout(watcher monitor.ConfigMonitor, methods *methodSet) (rpcinfo.TimeoutProvider, int64, func()) {
 	rpcTimeoutContainer := rpctimeout.NewContainer()
+	var lock sync.Mutex
 
 	onChangeCallback := func() {
+		lock.Lock()
+		defer lock.Unlock()
+
 		// the key is method name, wildcard "*" can match anything.
 		config := getFileConfig(watcher)
 		if config == nil {
 			return // config is nil, do nothing, log will be printed in getFileConfig
 		}
-		rpcTimeoutContainer.NotifyPolicyChange(config.Timeout)
+		rpcTimeoutContainer.NotifyPolicyChange(parser.ExpandMethods(config.Timeout, methods.list()))
 	}
 
-	keyRPCTimeout := watcher.RegisterCallback(onChangeCallback)
-	return rpcTimeoutContainer, keyRPCTimeout
+	keyRPCTimeout := watcher.RegisterCallbackFor("timeout", onChangeCallback)
+	removeListener := methods.onAdd(onChangeCallback)
+	return rpcTimeoutContainer, keyRPCTimeout, removeListener
 }
@@ -23,8 +23,10 @@ import (
 )
 
 type FileConfigClientSuite struct {
-	watcher monitor.ConfigMonitor
-	service string
+	watcher   monitor.ConfigMonitor
+	service   string
+	methods   *methodSet
+	probation *probation // nil if disabled
 }
 
 // NewSuite service is the destination service.
@@ -33,21 +35,40 @@ func NewSuite(service, key string, watcher filewatcher.FileWatcher, opts ...util
 	if err != nil {
 		panic(err)
 	}
+	cm.SetManager(&parser.ClientFileManager{})
+	monitor.Register(cm)
 
-	return &FileConfigClientSuite{
+	option := &utils.Options{}
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	s := &FileConfigClientSuite{
 		watcher: cm,
 		service: service,
+		methods: newMethodSet(option.Methods),
 	}
+	if option.Probation != nil {
+		s.probation = newProbation(cm, *option.Probation)
+	}
+	return s
 }
 
 // Options return a list client.Option
 func (s *FileConfigClientSuite) Options() []kitexclient.Option {
-	s.watcher.SetManager(&parser.ClientFileManager{})
-
-	opts := make([]kitexclient.Option, 0, 7)
-	opts = append(opts, WithRetryPolicy(s.watcher)...)
-	opts = append(opts, WithCircuitBreaker(s.service, s.watcher)...)
-	opts = append(opts, WithRPCTimeout(s.watcher)...)
+	opts := make([]kitexclient.Option, 0, 8)
+	opts = append(opts, withRetryPolicy(s.watcher, s.methods)...)
+	opts = append(opts, withCircuitBreaker(s.service, s.watcher, s.methods)...)
+	opts = append(opts, withRPCTimeout(s.watcher, s.methods)...)
+	opts = append(opts, kitexclient.WithMiddleware(s.methods.middleware))
+	if s.probation != nil {
+		keyProbation := s.watcher.RegisterChangeCallback(s.probation.onChange)
+		opts = append(opts, kitexclient.WithMiddleware(s.probation.middleware))
+		opts = append(opts, kitexclient.WithCloseCallbacks(func() error {
+			s.watcher.DeregisterCallback(keyProbation)
+			return nil
+		}))
+	}
 	opts = append(opts, kitexclient.WithCloseCallbacks(func() error {
 		s.watcher.Stop()
 		return nil
@@ -59,3 +80,35 @@ func (s *FileConfigClientSuite) Options() []kitexclient.Option {
 
 	return opts
 }
+
+// Monitor returns the monitor of the config, e.g. for History and Rollback.
+func (s *FileConfigClientSuite) Monitor() monitor.ConfigMonitor {
+	return s.watcher
+}
+
+// DryRun reports the effective policies per method that data, the content of the file, would apply to the client,
+// diffed against the current ones, which are empty before the first reload.
+// The method patterns are expanded to the known methods, and no policy is changed.
+func (s *FileConfigClientSuite) DryRun(data []byte) (*monitor.DryRunResult, error) {
+	r, err := s.watcher.DryRun(data)
+	if err != nil {
+		return nil, err
+	}
+	old, new := s.effective(r.Old), s.effective(r.New)
+	return &monitor.DryRunResult{Old: old, New: new, Diff: monitor.DiffConfigs(old, new)}, nil
+}
+
+// effective returns the policies of config keyed by method names, as they are given to the kitex containers.
+// A nil config has no policies.
+func (s *FileConfigClientSuite) effective(config interface{}) *parser.ClientFileConfig {
+	c, ok := config.(*parser.ClientFileConfig)
+	if !ok || c == nil {
+		return &parser.ClientFileConfig{}
+	}
+	methods := s.methods.list()
+	return &parser.ClientFileConfig{
+		Timeout:        parser.ExpandMethods(c.Timeout, methods),
+		Retry:          parser.ExpandMethods(c.Retry, methods),
+		Circuitbreaker: parser.ExpandMethods(c.Circuitbreaker, methods),
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+
+	"github.com/kitex-contrib/config-file/mock"
+	"github.com/kitex-contrib/config-file/parser"
+	"github.com/kitex-contrib/config-file/utils"
+)
+
+func TestSuiteDryRun(t *testing.T) {
+	s := NewSuite("Service", "Client", mock.NewMockFileWatcher(), func(o *utils.Options) {
+		o.Methods = []string{"GetUser", "GetOrder", "Put"}
+	})
+	defer s.Monitor().Stop()
+
+	r, err := s.DryRun([]byte(`{"Client": {"timeout": {"Get*": {"rpc_timeout_ms": 100}, "Put": {"rpc_timeout_ms": 200}}}}`))
+	if err != nil {
+		t.Fatalf("DryRun() error = %v", err)
+	}
+	config := r.New.(*parser.ClientFileConfig)
+	if len(config.Timeout) != 3 || config.Timeout["GetOrder"].RPCTimeoutMS != 100 {
+		t.Errorf("the patterns should be expanded to the methods, got %v", config.Timeout)
+	}
+	want := "timeout.GetOrder added; timeout.GetUser added; timeout.Put added"
+	if r.Diff.String() != want {
+		t.Errorf("DryRun() diff = %q, want %q", r.Diff, want)
+	}
+	if s.Monitor().Config() != nil {
+		t.Errorf("DryRun() should not apply the config")
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/cloudwego/kitex/pkg/klog"
 	"github.com/fsnotify/fsnotify"
+	"github.com/kitex-contrib/config-file/parser"
 	"github.com/kitex-contrib/config-file/utils"
 )
 
@@ -40,11 +41,13 @@ type FileWatcher interface {
 // FileWatcher is used for file monitoring
 type fileWatcher struct {
 	filePath  string                      // The path to the file to be monitored.
+	layers    *layers                     // Overlays merged on top of filePath, nil for a single file.
 	callbacks map[int64]func(data []byte) // Custom functions to be executed when the file changes.
 	watcher   *fsnotify.Watcher           // fsnotify file change watcher.
 	done      chan struct{}               // A channel for signaling the watcher to stop.
 	lock      sync.RWMutex                // mutex
 	counter   atomic.Int64                // unique id for callbacks, only increase
+	loads     loadStatus                  // result of the reads of the file
 }
 
 // NewFileWatcher creates a new FileWatcher instance.
@@ -73,9 +76,54 @@ func NewFileWatcher(filePath string) (FileWatcher, error) {
 	return fw, nil
 }
 
+// NewLayeredFileWatcher creates a FileWatcher which deep-merges environment overlays on top of a base file,
+// e.g. NewLayeredFileWatcher(parser.JSON, "kitex_client.json", "kitex_client.prod.json", "kitex_client.local.json").
+// Later layers override earlier ones, a null value deletes the key, and all layers are watched.
+// Callbacks receive the merged document encoded as json, which can be decoded as either json or yaml.
+func NewLayeredFileWatcher(kind parser.ConfigType, basePath string, overlayPaths ...string) (FileWatcher, error) {
+	paths := append([]string{basePath}, overlayPaths...)
+	for _, path := range paths {
+		exist, err := utils.PathExists(path)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			return nil, errors.New("file [" + path + "] not exist")
+		}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	fw := &fileWatcher{
+		filePath:  basePath,
+		layers:    &layers{kind: kind, paths: paths},
+		watcher:   watcher,
+		done:      make(chan struct{}),
+		callbacks: make(map[int64]func(data []byte), 0),
+	}
+
+	return fw, nil
+}
+
 // FilePath returns the file address that the current object is listening to
 func (fw *fileWatcher) FilePath() string { return fw.filePath }
 
+// Origin returns the file that the value at path was loaded from.
+// path is the list of keys from the document root, e.g. Origin("ClientName/ServiceName", "retry", "Echo").
+// Without overlays it is always the watched file.
+func (fw *fileWatcher) Origin(path ...string) string {
+	if fw.layers == nil {
+		return fw.filePath
+	}
+	return fw.layers.Origin(path...)
+}
+
+// Layered reports whether the watcher merges overlays, whose data is not the text of the watched file.
+func (fw *fileWatcher) Layered() bool { return fw.layers != nil }
+
 // CallbackSize returns the number of callback functions.
 func (fw *fileWatcher) CallbackSize() int {
 	fw.lock.RLock()
@@ -116,8 +164,11 @@ func (fw *fileWatcher) DeregisterCallback(uniqueID int64) {
 // This method will add the file to be monitored to the watcher and start the monitoring process instanctly.
 func (fw *fileWatcher) StartWatching() error {
 	fw.lock.Lock()
-	if err := fw.watcher.Add(fw.filePath); err != nil {
-		return err
+	for _, path := range fw.watchedPaths() {
+		if err := fw.watcher.Add(path); err != nil {
+			fw.lock.Unlock()
+			return err
+		}
 	}
 	fw.lock.Unlock()
 
@@ -157,7 +208,7 @@ func (fw *fileWatcher) start() {
 				}
 			}
 			if event.Has(fsnotify.Remove) {
-				klog.Warnf("[local] file %s is removed, stop watching", fw.filePath)
+				klog.Warnf("[local] file %s is removed, stop watching", event.Name)
 				fw.StopWatching()
 			}
 		case err, ok := <-fw.watcher.Errors:
@@ -171,14 +222,43 @@ func (fw *fileWatcher) start() {
 	}
 }
 
+// watchedPaths returns every file the watcher listens to.
+func (fw *fileWatcher) watchedPaths() []string {
+	if fw.layers == nil {
+		return []string{fw.filePath}
+	}
+	return fw.layers.paths
+}
+
+// readFile reads the watched file, merging the overlays if any.
+func (fw *fileWatcher) readFile() ([]byte, error) {
+	var data []byte
+	var err error
+	if fw.layers == nil {
+		data, err = os.ReadFile(fw.filePath)
+	} else {
+		data, err = fw.layers.load()
+	}
+	fw.loads.record(data, err)
+	return data, err
+}
+
 // CallOnceAll calls the callback function list once.
 func (fw *fileWatcher) CallOnceAll() error {
-	data, err := os.ReadFile(fw.filePath)
+	data, err := fw.readFile()
 	if err != nil {
 		return err
 	}
 
+	// copy the callbacks, which may register or deregister the others
+	fw.lock.RLock()
+	callbacks := make(map[int64]func(data []byte), len(fw.callbacks))
 	for key, callback := range fw.callbacks {
+		callbacks[key] = callback
+	}
+	fw.lock.RUnlock()
+
+	for key, callback := range callbacks {
 		if callback == nil {
 			fw.DeregisterCallback(key) // When encountering Nil's callback function, directly cancel it here.
 			klog.Warnf("[local] filewatcher callback %v is nil, deregister it", key)
@@ -191,12 +271,15 @@ func (fw *fileWatcher) CallOnceAll() error {
 
 // CallOnceSpecific calls the callback function once by uniqueID.
 func (fw *fileWatcher) CallOnceSpecific(uniqueID int64) error {
-	data, err := os.ReadFile(fw.filePath)
+	data, err := fw.readFile()
 	if err != nil {
 		return err
 	}
 
-	if callback, ok := fw.callbacks[uniqueID]; ok {
+	fw.lock.RLock()
+	callback, ok := fw.callbacks[uniqueID]
+	fw.lock.RUnlock()
+	if ok {
 		callback(data)
 	} else {
 		return errors.New("not found callback for id: " + strconv.FormatInt(uniqueID, 10))
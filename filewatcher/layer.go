@@ -0,0 +1,139 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filewatcher
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/bytedance/sonic"
+	"github.com/kitex-contrib/config-file/parser"
+)
+
+// layers merges a base file with its overlays, e.g.
+// kitex_client.json + kitex_client.prod.json + kitex_client.local.json.
+//
+// Layers are deep-merged in order:
+//   - objects are merged key by key, recursively
+//   - any other value in a later layer replaces the earlier one
+//   - a null value in a later layer deletes the key
+type layers struct {
+	kind   parser.ConfigType
+	paths  []string     // base file first, then overlays in override order
+	origin atomic.Value // *origin of the last merged result
+}
+
+// origin records which layer a value of the merged document came from.
+type origin struct {
+	layer    string
+	children map[string]*origin
+}
+
+// load reads every layer and returns the merged document encoded as json,
+// which is also valid yaml, so it can be decoded with the configured ConfigType.
+func (l *layers) load() ([]byte, error) {
+	p := parser.DefaultConfigParser()
+
+	var merged interface{}
+	root := &origin{}
+	for _, path := range l.paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var doc interface{}
+		if err := p.Decode(l.kind, data, &doc); err != nil {
+			return nil, fmt.Errorf("decode layer %s failed: %w", path, err)
+		}
+		if doc == nil {
+			continue // empty layer
+		}
+		merged = mergeLayer(merged, doc, path, root)
+	}
+
+	if merged == nil {
+		merged = map[string]interface{}{}
+	}
+	l.origin.Store(root)
+	return sonic.Marshal(merged)
+}
+
+// Origin returns the layer that the value at path was loaded from, or empty if the path does not exist.
+func (l *layers) Origin(path ...string) string {
+	o, _ := l.origin.Load().(*origin)
+	for _, key := range path {
+		if o == nil {
+			return ""
+		}
+		o = o.children[key]
+	}
+	if o == nil {
+		return ""
+	}
+	return o.layer
+}
+
+// mergeLayer merges src from layer into dst and records the origin of every value it writes.
+func mergeLayer(dst, src interface{}, layer string, o *origin) interface{} {
+	srcMap, ok := src.(map[string]interface{})
+	if !ok {
+		markOrigin(src, layer, o)
+		return src
+	}
+	dstMap, ok := dst.(map[string]interface{})
+	if !ok {
+		// overriding a non-object value, start over from this layer
+		dstMap = make(map[string]interface{}, len(srcMap))
+		o.children = nil
+	}
+	if o.children == nil {
+		o.children = make(map[string]*origin, len(srcMap))
+	}
+
+	o.layer = layer
+	for key, value := range srcMap {
+		if value == nil {
+			delete(dstMap, key)
+			delete(o.children, key)
+			continue
+		}
+		child, ok := o.children[key]
+		if !ok {
+			child = &origin{}
+			o.children[key] = child
+		}
+		dstMap[key] = mergeLayer(dstMap[key], value, layer, child)
+	}
+	return dstMap
+}
+
+// markOrigin marks value and everything below it as coming from layer.
+func markOrigin(value interface{}, layer string, o *origin) {
+	o.layer = layer
+	o.children = nil
+
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	o.children = make(map[string]*origin, len(m))
+	for key, v := range m {
+		child := &origin{}
+		markOrigin(v, layer, child)
+		o.children[key] = child
+	}
+}
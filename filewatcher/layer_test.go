@@ -0,0 +1,56 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filewatcher
+
+import (
+	"testing"
+
+	"github.com/kitex-contrib/config-file/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	basePath    = "./../testdata/layer_base.json"
+	overlayPath = "./../testdata/layer_prod.json"
+	key         = "ClientName/ServiceName"
+)
+
+func TestLayeredFileWatcher(t *testing.T) {
+	fw, err := NewLayeredFileWatcher(parser.JSON, basePath, overlayPath)
+	assert.Nil(t, err)
+	assert.Equal(t, basePath, fw.FilePath())
+
+	var got []byte
+	id := fw.RegisterCallback(func(data []byte) { got = data })
+	assert.Nil(t, fw.CallOnceSpecific(id))
+
+	manager := parser.ClientFileManager{}
+	assert.Nil(t, parser.DefaultConfigParser().Decode(parser.JSON, got, &manager))
+
+	config := manager[key]
+	assert.Equal(t, 100, config.Timeout["*"].ConnTimeoutMS)
+	assert.Equal(t, 1000, config.Timeout["*"].RPCTimeoutMS)
+	assert.Empty(t, config.Circuitbreaker)
+
+	lw := fw.(*fileWatcher)
+	assert.Equal(t, basePath, lw.Origin(key, "timeout", "*", "conn_timeout_ms"))
+	assert.Equal(t, overlayPath, lw.Origin(key, "timeout", "*", "rpc_timeout_ms"))
+	assert.Equal(t, "", lw.Origin(key, "circuitbreaker"))
+}
+
+func TestLayeredFileWatcherNotExist(t *testing.T) {
+	_, err := NewLayeredFileWatcher(parser.JSON, basePath, "./../testdata/not_exist.json")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filewatcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kitex-contrib/config-file/utils"
+)
+
+// Status is the state of a FileWatcher, for introspection.
+type Status struct {
+	FilePath     string
+	Files        []string  // every watched file, the base file first, then the overlays
+	LastLoad     time.Time // when the file was last read successfully, zero if never
+	Hash         string    // sha256 of the data last read successfully, merged for overlays
+	Loads        int64     // number of the successful reads
+	LastError    error     // error of the last read, nil if it succeeded
+	CallbackSize int
+}
+
+// statusWatcher is implemented by the file watchers which report their Status, such as the ones of this package.
+type statusWatcher interface {
+	Status() Status
+}
+
+// StatusOf returns the status of fw, and false if fw does not report one,
+// in which case only its file path and the number of callbacks are known.
+func StatusOf(fw FileWatcher) (Status, bool) {
+	if s, ok := fw.(statusWatcher); ok {
+		return s.Status(), true
+	}
+	return Status{
+		FilePath:     fw.FilePath(),
+		Files:        []string{fw.FilePath()},
+		CallbackSize: fw.CallbackSize(),
+	}, false
+}
+
+// loadStatus records the reads of the file.
+type loadStatus struct {
+	lock     sync.RWMutex
+	lastLoad time.Time
+	hash     string
+	loads    int64
+	err      error
+}
+
+func (s *loadStatus) record(data []byte, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.err = err
+	if err == nil {
+		s.lastLoad, s.hash = time.Now(), utils.Hash(data)
+		s.loads++
+	}
+}
+
+// Status returns the state of the watcher.
+func (fw *fileWatcher) Status() Status {
+	status := Status{
+		FilePath:     fw.filePath,
+		Files:        append([]string(nil), fw.watchedPaths()...),
+		CallbackSize: fw.CallbackSize(),
+	}
+	fw.loads.lock.RLock()
+	defer fw.loads.lock.RUnlock()
+	status.LastLoad, status.Hash, status.Loads, status.LastError = fw.loads.lastLoad, fw.loads.hash, fw.loads.loads, fw.loads.err
+	return status
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filewatcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kitex-contrib/config-file/parser"
+	"github.com/kitex-contrib/config-file/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := []byte(`{"Test1": {}}`)
+	assert.Nil(t, os.WriteFile(path, data, 0o644))
+
+	fw, err := NewFileWatcher(path)
+	assert.Nil(t, err)
+	status, ok := StatusOf(fw)
+	assert.True(t, ok)
+	assert.Equal(t, []string{path}, status.Files)
+	assert.True(t, status.LastLoad.IsZero())
+
+	fw.RegisterCallback(func(data []byte) {})
+	assert.Nil(t, fw.CallOnceAll())
+	status, _ = StatusOf(fw)
+	assert.Equal(t, utils.Hash(data), status.Hash)
+	assert.Equal(t, int64(1), status.Loads)
+	assert.Equal(t, 1, status.CallbackSize)
+	assert.Nil(t, status.LastError)
+
+	// a failed read keeps the last successful one
+	assert.Nil(t, os.Remove(path))
+	assert.NotNil(t, fw.CallOnceAll())
+	status, _ = StatusOf(fw)
+	assert.NotNil(t, status.LastError)
+	assert.Equal(t, utils.Hash(data), status.Hash)
+
+	lw, err := NewLayeredFileWatcher(parser.JSON, basePath, overlayPath)
+	assert.Nil(t, err)
+	status, _ = StatusOf(lw)
+	assert.Equal(t, []string{basePath, overlayPath}, status.Files)
+
+	// a custom FileWatcher without Status
+	status, ok = StatusOf(struct{ FileWatcher }{fw})
+	assert.False(t, ok)
+	assert.Equal(t, []string{path}, status.Files)
+	assert.Equal(t, 1, status.CallbackSize)
+}
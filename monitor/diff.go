@@ -0,0 +1,172 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ChangeType is the type of a Change.
+type ChangeType string
+
+const (
+	Added    ChangeType = "added"
+	Removed  ChangeType = "removed"
+	Modified ChangeType = "changed"
+)
+
+// Change is a difference between two configs.
+type Change struct {
+	// Path is the json names from the config to the value, e.g. [retry Echo failure_policy stop_policy max_retry_times],
+	// empty for the whole config.
+	Path []string
+	Type ChangeType
+	Old  interface{} // nil if added
+	New  interface{} // nil if removed
+}
+
+// String returns the change for logs, e.g. "retry.Echo.failure_policy.stop_policy.max_retry_times changed 3→2".
+func (c Change) String() string {
+	path := strings.Join(c.Path, ".")
+	if path == "" {
+		path = "config"
+	}
+	if c.Type == Modified {
+		return fmt.Sprintf("%s changed %v→%v", path, c.Old, c.New)
+	}
+	return path + " " + string(c.Type)
+}
+
+// Diff is the list of the changes between two configs, in the order of the fields and the sorted map keys.
+type Diff []Change
+
+func (d Diff) String() string {
+	changes := make([]string, 0, len(d))
+	for _, c := range d {
+		changes = append(changes, c.String())
+	}
+	return strings.Join(changes, "; ")
+}
+
+// Affects reports whether any of the changes is at path, under it, or replaces a value containing it,
+// e.g. a change of retry.Echo.type affects "retry" and "retry.Echo", but not "timeout".
+func (d Diff) Affects(path ...string) bool {
+	for _, c := range d {
+		if hasPrefix(c.Path, path) || hasPrefix(path, c.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i, name := range prefix {
+		if path[i] != name {
+			return false
+		}
+	}
+	return true
+}
+
+// DiffConfigs compares two configs of the same type field by field, following the json names of the fields.
+// Maps are compared key by key, and the other values, such as slices, as a whole.
+func DiffConfigs(old, new interface{}) Diff {
+	var d Diff
+	diffValues(nil, reflect.ValueOf(old), reflect.ValueOf(new), &d)
+	return d
+}
+
+func diffValues(path []string, old, new reflect.Value, d *Diff) {
+	old, new = indirect(old), indirect(new)
+	switch {
+	case !old.IsValid() && !new.IsValid():
+		return
+	case !old.IsValid():
+		*d = append(*d, Change{Path: path, Type: Added, New: new.Interface()})
+		return
+	case !new.IsValid():
+		*d = append(*d, Change{Path: path, Type: Removed, Old: old.Interface()})
+		return
+	case old.Type() != new.Type():
+		*d = append(*d, Change{Path: path, Type: Modified, Old: old.Interface(), New: new.Interface()})
+		return
+	}
+
+	switch old.Kind() {
+	case reflect.Struct:
+		for i := 0; i < old.NumField(); i++ {
+			if name, ok := jsonName(old.Type().Field(i)); ok {
+				diffValues(appendPath(path, name), old.Field(i), new.Field(i), d)
+			}
+		}
+	case reflect.Map:
+		keys := map[string]reflect.Value{}
+		for _, m := range []reflect.Value{old, new} {
+			for _, key := range m.MapKeys() {
+				keys[fmt.Sprint(key.Interface())] = key
+			}
+		}
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			diffValues(appendPath(path, name), old.MapIndex(keys[name]), new.MapIndex(keys[name]), d)
+		}
+	case reflect.Func, reflect.Chan:
+		// not part of the config file
+	default:
+		if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+			*d = append(*d, Change{Path: path, Type: Modified, Old: old.Interface(), New: new.Interface()})
+		}
+	}
+}
+
+// indirect dereferences pointers and interfaces, nil ones become the zero Value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// jsonName returns the json name of an exported field, false if it is not encoded.
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	}
+	return name, true
+}
+
+func appendPath(path []string, name string) []string {
+	return append(path[:len(path):len(path)], name)
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"testing"
+
+	"github.com/kitex-contrib/config-file/mock"
+	"github.com/kitex-contrib/config-file/parser"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	decode := func(data string) *parser.ClientFileConfig {
+		manager := &parser.ClientFileManager{}
+		if err := parser.DefaultConfigParser().Decode(parser.JSON, []byte(data), manager); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		return manager.GetConfig("Client").(*parser.ClientFileConfig)
+	}
+	old := decode(`{"Client": {
+		"retry": {"Echo": {"enable": true, "type": 0, "failure_policy": {"stop_policy": {"max_retry_times": 3}}}},
+		"circuitbreaker": {"Get": {"enable": true, "err_rate": 0.5, "min_sample": 100}}
+	}}`)
+	new := decode(`{"Client": {
+		"retry": {"Echo": {"enable": true, "type": 0, "failure_policy": {"stop_policy": {"max_retry_times": 2}}}},
+		"timeout": {"Echo": {"rpc_timeout_ms": 100}}
+	}}`)
+
+	want := []string{
+		"timeout.Echo added",
+		"retry.Echo.failure_policy.stop_policy.max_retry_times changed 3→2",
+		"circuitbreaker.Get removed",
+	}
+	diff := DiffConfigs(old, new)
+	if len(diff) != len(want) {
+		t.Fatalf("DiffConfigs() = %v, want %v", diff, want)
+	}
+	for i, c := range diff {
+		if c.String() != want[i] {
+			t.Errorf("change %d = %q, want %q", i, c.String(), want[i])
+		}
+	}
+	if diff[1].Old != 3 || diff[1].New != 2 || diff[2].New != nil {
+		t.Errorf("unexpected values of the changes %#v", diff)
+	}
+
+	if diff := DiffConfigs(old, decode(`{"Client": {
+		"retry": {"Echo": {"enable": true, "type": 0, "failure_policy": {"stop_policy": {"max_retry_times": 3}}}},
+		"circuitbreaker": {"Get": {"enable": true, "err_rate": 0.5, "min_sample": 100}}
+	}}`)); len(diff) != 0 {
+		t.Errorf("equal configs should have no changes, got %v", diff)
+	}
+	if diff := DiffConfigs(nil, new); len(diff) != 1 || diff[0].String() != "config added" {
+		t.Errorf("DiffConfigs(nil, new) = %v", diff)
+	}
+}
+
+func TestRegisterChangeCallback(t *testing.T) {
+	cm, err := NewConfigMonitor("Test1", mock.NewMockFileWatcher())
+	if err != nil {
+		t.Fatalf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ServerFileManager{})
+	c := cm.(*configMonitor)
+
+	var diffs []Diff
+	var olds []interface{}
+	cm.RegisterChangeCallback(func(old, new interface{}, diff Diff) {
+		olds = append(olds, old)
+		diffs = append(diffs, diff)
+	})
+	for _, data := range []string{
+		`{"Test1": {"limit": {"qps_limit": 100}}}`,
+		`{"Test1": {"limit": {"qps_limit": 200}}}`,
+	} {
+		if err := c.reload([]byte(data)); err != nil {
+			t.Errorf("reload() error = %v", err)
+		}
+	}
+	if len(diffs) != 2 || olds[0] != nil || olds[1].(*parser.ServerFileConfig).Limit.QPSLimit != 100 {
+		t.Fatalf("unexpected callbacks, old configs %v", olds)
+	}
+	if got := diffs[1].String(); got != "limit.qps_limit changed 100→200" {
+		t.Errorf("unexpected diff %q", got)
+	}
+}
+
+func TestRegisterCallbackFor(t *testing.T) {
+	cm, err := NewConfigMonitor("Client", mock.NewMockFileWatcher())
+	if err != nil {
+		t.Fatalf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ClientFileManager{})
+	c := cm.(*configMonitor)
+
+	calls := map[string]int{}
+	for _, path := range []string{"retry", "timeout", "timeout.Echo", ""} {
+		path := path
+		cm.RegisterCallbackFor(path, func() { calls[path]++ })
+	}
+	for _, data := range []string{
+		`{"Client": {"timeout": {"Echo": {"rpc_timeout_ms": 100}, "Get": {"rpc_timeout_ms": 100}}}}`,
+		`{"Client": {"timeout": {"Echo": {"rpc_timeout_ms": 100}, "Get": {"rpc_timeout_ms": 200}}}}`,
+	} {
+		if err := c.reload([]byte(data)); err != nil {
+			t.Errorf("reload() error = %v", err)
+		}
+	}
+	// all the callbacks are invoked for the first update, only the changed sections for the second one
+	want := map[string]int{"retry": 1, "timeout": 2, "timeout.Echo": 1, "": 2}
+	for path, n := range want {
+		if calls[path] != n {
+			t.Errorf("callback for %q invoked %d times, want %d", path, calls[path], n)
+		}
+	}
+
+	diff := Diff{{Path: []string{"retry", "Echo", "type"}, Type: Modified}}
+	if !diff.Affects("retry") || !diff.Affects("retry", "Echo", "type", "x") || diff.Affects("retry", "Get") || diff.Affects("timeout") {
+		t.Errorf("unexpected Affects() of %v", diff)
+	}
+}
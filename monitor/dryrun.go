@@ -0,0 +1,44 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import "errors"
+
+// DryRunResult is what a reload would change.
+type DryRunResult struct {
+	Old  interface{} // the current config, nil if none
+	New  interface{} // the config that would be applied, equal to Old if the current one would be kept
+	Diff Diff        // changes from Old to New
+}
+
+// DryRun runs data, the content of the file, through the same decoding, extends and validation as the reloads,
+// and reports the changes it would make to the current config. Nothing is applied and no callback is invoked.
+// The error is the one the reload would be rejected with.
+func (c *configMonitor) DryRun(data []byte) (*DryRunResult, error) {
+	if manager, _, _ := c.decoding(); manager == nil {
+		return nil, errors.New("not set manager for config file")
+	}
+
+	// the decoding is not shared, which would replace the cached one of the file
+	_, config, err := c.resolve(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	old := c.Config()
+	if config == nil {
+		config = old
+	}
+	return &DryRunResult{Old: old, New: config, Diff: DiffConfigs(old, config)}, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"testing"
+
+	"github.com/kitex-contrib/config-file/mock"
+	"github.com/kitex-contrib/config-file/parser"
+)
+
+func TestDryRun(t *testing.T) {
+	cm, err := NewConfigMonitor("Test1", mock.NewMockFileWatcher())
+	if err != nil {
+		t.Fatalf("NewConfigMonitor() error = %v", err)
+	}
+	if _, err := cm.DryRun([]byte(`{}`)); err == nil {
+		t.Errorf("DryRun() should fail without manager")
+	}
+	cm.SetManager(&parser.ServerFileManager{})
+	c := cm.(*configMonitor)
+	called := 0
+	cm.RegisterCallback(func() { called++ })
+
+	if err := c.reload([]byte(`{"Test1": {"limit": {"qps_limit": 100}}}`)); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	r, err := cm.DryRun([]byte(`{"base": {"limit": {"connection_limit": 50}}, "Test1": {"extends": "base", "limit": {"qps_limit": 200}}}`))
+	if err != nil {
+		t.Fatalf("DryRun() error = %v", err)
+	}
+	// the config is the one merged with base
+	want := "limit.connection_limit changed 0→50; limit.qps_limit changed 100→200"
+	if r.Diff.String() != want || r.Old != cm.Config() {
+		t.Errorf("DryRun() diff = %q, want %q", r.Diff, want)
+	}
+	if _, err := cm.DryRun([]byte(`{"Test1": {"limit": {"qps_limit": -1}}}`)); err == nil {
+		t.Errorf("DryRun() should fail for the invalid config")
+	}
+
+	status := cm.Status()
+	if got := cm.Config().(*parser.ServerFileConfig).Limit.QPSLimit; got != 100 || called != 1 || status.Revision != 1 || status.LastError != nil {
+		t.Errorf("DryRun() should not apply anything, got qps_limit %d, %d callbacks, status %+v", got, called, status)
+	}
+}
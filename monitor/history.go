@@ -0,0 +1,78 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+)
+
+// DefaultHistorySize is the number of the revisions kept by a monitor if utils.Options.History is not set.
+const DefaultHistorySize = 10
+
+// Revision is a config applied by the monitor.
+type Revision struct {
+	ID     int64       // increases by one with each applied config, starting from 1
+	Time   time.Time   // when the config was applied
+	Hash   string      // sha256 of the content of the file the config was decoded from
+	Config interface{} // the config of the key, which must not be modified
+}
+
+// History returns the last applied revisions, oldest first, the last one is the current config.
+func (c *configMonitor) History() []Revision {
+	list := c.historyList()
+	revisions := make([]Revision, 0, len(list))
+	for _, s := range list {
+		revisions = append(revisions, Revision{ID: s.revision, Time: s.time, Hash: s.hash, Config: s.config})
+	}
+	return revisions
+}
+
+// Rollback applies the config of an earlier revision in History again as a new revision, and invokes the callbacks
+// as if the file had changed. The registered validators must accept the config again. The file is not modified, and its next change is applied as usual.
+func (c *configMonitor) Rollback(revision int64) error {
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+
+	for _, s := range c.historyList() {
+		if s.revision == revision {
+			if err := c.validate(s.config); err != nil {
+				return err
+			}
+			klog.Infof("[local] roll back config of key %s to revision %d\n", c.key, revision)
+			return c.apply(&snapshot{manager: s.manager, config: s.config, hash: s.hash}, false)
+		}
+	}
+	return fmt.Errorf("revision %d of key %s not found in the history", revision, c.key)
+}
+
+// record appends s to the history, dropping the oldest revisions beyond the size, it must be called under reloadLock.
+func (c *configMonitor) record(s *snapshot) {
+	list := c.historyList()
+	if len(list) >= c.historySize {
+		list = list[len(list)-c.historySize+1:]
+	}
+	updated := make([]*snapshot, len(list), len(list)+1)
+	copy(updated, list)
+	c.history.Store(append(updated, s))
+}
+
+// historyList returns the applied snapshots, which must not be modified.
+func (c *configMonitor) historyList() []*snapshot {
+	list, _ := c.history.Load().([]*snapshot)
+	return list
+}
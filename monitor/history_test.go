@@ -0,0 +1,74 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kitex-contrib/config-file/mock"
+	"github.com/kitex-contrib/config-file/parser"
+	"github.com/kitex-contrib/config-file/utils"
+)
+
+func TestHistoryAndRollback(t *testing.T) {
+	cm, err := NewConfigMonitor("Test1", mock.NewMockFileWatcher(), func(o *utils.Options) { o.History = 3 })
+	if err != nil {
+		t.Fatalf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ServerFileManager{})
+	c := cm.(*configMonitor)
+
+	var limits []int64
+	cm.RegisterCallbackFor("limit", func() {
+		limits = append(limits, cm.Config().(*parser.ServerFileConfig).Limit.QPSLimit)
+	})
+	for _, qps := range []int{100, 200, 200, 300, 400} {
+		if err := c.reload([]byte(fmt.Sprintf(`{"Test1": {"limit": {"qps_limit": %d}}}`, qps))); err != nil {
+			t.Errorf("reload() error = %v", err)
+		}
+	}
+
+	// the unchanged config is not a revision, and only the last 3 are kept
+	history := cm.History()
+	if len(history) != 3 || history[0].ID != 2 || history[2].ID != 4 {
+		t.Fatalf("unexpected history %+v", history)
+	}
+	if history[0].Hash == history[1].Hash || len(history[0].Hash) != 64 || history[0].Time.After(history[1].Time) {
+		t.Errorf("unexpected hashes or times %+v", history)
+	}
+
+	if err := cm.Rollback(1); err == nil {
+		t.Errorf("Rollback() should fail for a dropped revision")
+	}
+	if err := cm.Rollback(2); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	current := cm.History()[2]
+	if got := cm.Config().(*parser.ServerFileConfig).Limit.QPSLimit; got != 200 || current.ID != 5 || current.Hash != history[0].Hash {
+		t.Errorf("Rollback() should apply revision 2 as revision 5, got qps_limit %d, revision %+v", got, current)
+	}
+	if len(limits) != 5 || limits[4] != 200 {
+		t.Errorf("Rollback() should invoke the callbacks, got %v", limits)
+	}
+
+	// the next change of the file is applied as usual
+	if err := c.reload([]byte(`{"Test1": {"limit": {"qps_limit": 400}}}`)); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	if got := cm.Config().(*parser.ServerFileConfig).Limit.QPSLimit; got != 400 {
+		t.Errorf("the file should be applied after Rollback(), got qps_limit %d", got)
+	}
+}
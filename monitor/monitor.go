@@ -16,8 +16,13 @@ package monitor
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/cloudwego/kitex/pkg/klog"
 	"github.com/kitex-contrib/config-file/filewatcher"
@@ -28,6 +33,7 @@ import (
 type ConfigMonitor interface {
 	Key() string
 	Config() interface{}
+	Origin(path ...string) string
 	CallbackSize() int
 	Start() error
 	WatcherID() int64
@@ -37,21 +43,76 @@ type ConfigMonitor interface {
 	SetParams(params *parser.ConfigParam)
 	ConfigParse(kind parser.ConfigType, data []byte, config interface{}) error
 	RegisterCallback(callback func()) int64
+	RegisterCallbackFor(path string, callback func()) int64
+	RegisterChangeCallback(callback ChangeCallback) int64
 	DeregisterCallback(uniqueID int64)
+	History() []Revision
+	Rollback(revision int64) error
+	RegisterValidator(validate func(config interface{}) error) int64
+	DeregisterValidator(uniqueID int64)
+	Status() Status
+	DryRun(data []byte) (*DryRunResult, error)
 }
 
+// ChangeCallback is invoked with the previous and the current configs of the key and the changes between them.
+// The previous config is nil for the first update, and both configs must not be modified.
+type ChangeCallback func(old, new interface{}, diff Diff)
+
+// configMonitor is safe for concurrent use: the config is an immutable snapshot swapped atomically,
+// and the callbacks are a list copied on write, so readers never block the reloads.
 type configMonitor struct {
-	// support customise parser
-	parser      parser.ConfigParser     // Parser for the config file
-	params      *parser.ConfigParam     // params for the config file
-	manager     parser.ConfigManager    // Manager for the config file
-	config      interface{}             // config details
-	fileWatcher filewatcher.FileWatcher // local config file watcher
-	callbacks   map[int64]func()        // callbacks when config file changed
-	key         string                  // key of the config in the config file
-	id          int64                   // unique id for filewatcher to register/deregister
-	lock        sync.RWMutex            // mutex
-	counter     atomic.Int64            // unique id for callbacks, only increase
+	// support customise parser, guarded by lock
+	parser     parser.ConfigParser  // Parser for the config file
+	params     *parser.ConfigParam  // params for the config file
+	manager    parser.ConfigManager // Manager for the config file, only its type is used
+	baseParser parser.ConfigParser  // the parser set by SetParser, before wrapped by strict and template
+
+	current          atomic.Value                // *snapshot of the last successful reload
+	fileWatcher      filewatcher.FileWatcher     // local config file watcher
+	callbacks        atomic.Value                // []callbackEntry when config file changed, copied on write under lock
+	key              string                      // key of the config in the config file
+	strict           bool                        // reject unknown fields in the config file
+	template         bool                        // render the config file as a Go template before decoding
+	group            atomic.Pointer[decodeGroup] // shares the decoding with the other monitors of the file, nil if not started
+	notify           atomic.Bool                 // invoke the callbacks on the next reload even if the config is unchanged
+	id               atomic.Int64                // unique id for filewatcher to register/deregister
+	lock             sync.RWMutex                // mutex
+	reloadLock       sync.Mutex                  // serializes the reloads
+	counter          atomic.Int64                // unique id for callbacks and validators, only increase
+	validators       atomic.Value                // []validatorEntry of the new configs, copied on write under lock
+	history          atomic.Value                // []*snapshot applied, oldest first, copied on write under reloadLock
+	historySize      int                         // max length of history
+	missingKeyPolicy utils.MissingKeyPolicy      // what to do when the key is missing from the file
+	revision         int64                       // id of the last applied snapshot, guarded by reloadLock
+	loaded           atomic.Pointer[loadResult]  // result of the reloads, nil if none
+}
+
+// callbackEntry is an element of the callback list, which is never modified once stored.
+type callbackEntry struct {
+	id       int64
+	callback func()
+	change   ChangeCallback // set instead of callback by RegisterChangeCallback
+	path     []string       // invoke callback only if the config at path changed, nil for any change
+}
+
+// originWatcher is implemented by the file watchers which know the file each value is loaded from,
+// see filewatcher.NewLayeredFileWatcher.
+type originWatcher interface {
+	Origin(path ...string) string
+}
+
+// layeredWatcher is implemented by the file watchers which merge overlays, see filewatcher.NewLayeredFileWatcher.
+type layeredWatcher interface {
+	Layered() bool
+}
+
+// snapshot is the immutable result of a reload, it is replaced as a whole and never modified.
+type snapshot struct {
+	manager  parser.ConfigManager // the whole decoded file
+	config   interface{}          // config details of the key
+	hash     string               // hash of the file content
+	revision int64                // set when applied
+	time     time.Time            // set when applied
 }
 
 // NewConfigMonitor init a monitor for the config file
@@ -72,84 +133,164 @@ func NewConfigMonitor(key string, watcher filewatcher.FileWatcher, opts ...utils
 		opt(option)
 	}
 
-	return &configMonitor{
-		fileWatcher: watcher,
-		key:         key,
-		callbacks:   make(map[int64]func(), 0),
-		parser:      option.Parser,
-		params:      option.Params,
-	}, nil
+	cm := &configMonitor{
+		fileWatcher:      watcher,
+		key:              key,
+		params:           option.Params,
+		strict:           option.Strict,
+		template:         option.Template,
+		historySize:      option.History,
+		missingKeyPolicy: option.MissingKey,
+	}
+	if cm.historySize <= 0 {
+		cm.historySize = DefaultHistorySize
+	}
+	cm.SetParser(option.Parser)
+	return cm, nil
 }
 
 // Key return the key of the config file
 func (c *configMonitor) Key() string { return c.key }
 
 // Config return the config details
-func (c *configMonitor) Config() interface{} { return c.config }
+func (c *configMonitor) Config() interface{} {
+	if s, ok := c.current.Load().(*snapshot); ok {
+		return s.config
+	}
+	return nil
+}
 
-// CallbackSize return the size of the callbacks
-func (c *configMonitor) CallbackSize() int {
-	c.lock.RLock()
-	defer c.lock.RUnlock()
-	return len(c.callbacks)
+// Origin return the file that the config value at path was loaded from,
+// path is relative to the key, e.g. Origin("retry", "Echo").
+// A value which the key gets from the keys it extends or the wildcard keys is looked up in the key setting it.
+// It is only different from the base file when the filewatcher merges overlays.
+func (c *configMonitor) Origin(path ...string) string {
+	keys := []string{c.key}
+	if s, ok := c.current.Load().(*snapshot); ok && s.manager != nil {
+		keys = sourceKeys(s.manager, c.key)
+	}
+	for _, key := range keys {
+		if origin := c.origin(append([]string{key}, path...)...); origin != "" {
+			return origin
+		}
+	}
+	return ""
+}
+
+// origin returns the file that the value at path from the document root was loaded from,
+// which is the watched file if the watcher does not know it.
+func (c *configMonitor) origin(path ...string) string {
+	if o, ok := c.fileWatcher.(originWatcher); ok {
+		return o.Origin(path...)
+	}
+	return c.fileWatcher.FilePath()
 }
 
+// CallbackSize return the size of the callbacks
+func (c *configMonitor) CallbackSize() int { return len(c.callbackList()) }
+
 // WatcherID return the unique id of the filewatcher
-func (c *configMonitor) WatcherID() int64 { return c.id }
+func (c *configMonitor) WatcherID() int64 { return c.id.Load() }
 
 // Start starts the file watch progress
 func (c *configMonitor) Start() error {
-	if c.manager == nil {
+	c.lock.RLock()
+	manager := c.manager
+	c.lock.RUnlock()
+	if manager == nil {
 		return errors.New("not set manager for config file")
 	}
 
-	c.id = c.fileWatcher.RegisterCallback(c.parseHandler)
+	c.group.Store(joinGroup(c))
+	c.notify.Store(true)
+	id := c.fileWatcher.RegisterCallback(c.parseHandler)
+	c.id.Store(id)
 
-	return c.fileWatcher.CallOnceSpecific(c.id)
+	return c.fileWatcher.CallOnceSpecific(id)
 }
 
 // Stop stops the file watch progress
 func (c *configMonitor) Stop() {
-	for k := range c.callbacks {
-		c.DeregisterCallback(k)
+	for _, e := range c.callbackList() {
+		c.DeregisterCallback(e.id)
 	}
 
 	// deregister current object's parseHandler from filewatcher
-	c.fileWatcher.DeregisterCallback(c.id)
+	c.fileWatcher.DeregisterCallback(c.id.Load())
+	if g := c.group.Swap(nil); g != nil {
+		g.leave(c.key)
+	}
+	Deregister(c)
 }
 
-// SetManager set the manager for the config file
-func (c *configMonitor) SetManager(manager parser.ConfigManager) { c.manager = manager }
+// SetManager set the manager for the config file, each reload decodes into a new instance of its type
+func (c *configMonitor) SetManager(manager parser.ConfigManager) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.manager = manager
+}
 
 // SetParser set the parser for the config file
-func (c *configMonitor) SetParser(parser parser.ConfigParser) {
-	c.parser = parser
+func (c *configMonitor) SetParser(p parser.ConfigParser) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.baseParser = p
+	if c.template {
+		p = parser.TemplateParser(p)
+	}
+	if c.strict {
+		p = parser.StrictParser(p)
+	}
+	c.parser = p
 }
 
 // SetParams set the params for the config file, such as file type
 func (c *configMonitor) SetParams(params *parser.ConfigParam) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	c.params = params
 }
 
 // ConfigParse call configMonitor.parser.Decode()
 func (c *configMonitor) ConfigParse(kind parser.ConfigType, data []byte, config interface{}) error {
-	return c.parser.Decode(kind, data, config)
+	c.lock.RLock()
+	p := c.parser
+	c.lock.RUnlock()
+	return p.Decode(kind, data, config)
 }
 
 // RegisterCallback add callback function, it will be called when file changed, return key for deregister
 func (c *configMonitor) RegisterCallback(callback func()) int64 {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	return c.register(callbackEntry{callback: callback})
+}
 
-	if c.callbacks == nil {
-		c.callbacks = make(map[int64]func())
+// RegisterCallbackFor add callback function, it will be called only when the config at path changed,
+// path is the dot separated names in the file relative to the key, e.g. "retry" or "retry.Echo", return key for deregister
+func (c *configMonitor) RegisterCallbackFor(path string, callback func()) int64 {
+	if path == "" {
+		return c.RegisterCallback(callback)
 	}
+	return c.register(callbackEntry{callback: callback, path: strings.Split(path, ".")})
+}
 
-	key := c.counter.Add(1)
-	c.callbacks[key] = callback
+// RegisterChangeCallback add callback function receiving the previous and the current configs and the diff between them,
+// it will be called when the config changed, return key for deregister
+func (c *configMonitor) RegisterChangeCallback(callback ChangeCallback) int64 {
+	return c.register(callbackEntry{change: callback})
+}
 
-	klog.Debugf("[local] config monitor registered callback, id: %v\n", key)
-	return key
+func (c *configMonitor) register(e callbackEntry) int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e.id = c.counter.Add(1)
+	list := c.callbackList()
+	updated := make([]callbackEntry, len(list), len(list)+1)
+	copy(updated, list)
+	c.callbacks.Store(append(updated, e))
+
+	klog.Debugf("[local] config monitor registered callback, id: %v\n", e.id)
+	return e.id
 }
 
 // DeregisterCallback remove callback function.
@@ -157,39 +298,317 @@ func (c *configMonitor) DeregisterCallback(key int64) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	if _, exists := c.callbacks[key]; !exists {
-		klog.Warnf("[local] ConfigMonitor callback %s not registered", key)
+	list := c.callbackList()
+	updated := make([]callbackEntry, 0, len(list))
+	for _, e := range list {
+		if e.id != key {
+			updated = append(updated, e)
+		}
+	}
+	if len(updated) == len(list) {
+		klog.Warnf("[local] ConfigMonitor callback %v not registered", key)
 		return
 	}
-	delete(c.callbacks, key)
+	c.callbacks.Store(updated)
+}
+
+// callbackList returns the current callbacks, which must not be modified.
+func (c *configMonitor) callbackList() []callbackEntry {
+	list, _ := c.callbacks.Load().([]callbackEntry)
+	return list
+}
+
+// decoding returns the settings of decoding the file.
+func (c *configMonitor) decoding() (parser.ConfigManager, parser.ConfigParser, *parser.ConfigParam) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.manager, c.parser, c.params
 }
 
 // parseHandler parse and invoke each function in the callbacks array
 func (c *configMonitor) parseHandler(data []byte) {
-	resp := c.manager
+	if err := c.reload(data); err != nil {
+		c.logReloadError(err)
+		return
+	}
+	klog.Infof("[local] config parse and update complete \n")
+}
+
+// reload decodes and validates the config of the key, the current config is kept if any step fails.
+// The callbacks are skipped if the config is not changed.
+func (c *configMonitor) reload(data []byte) (err error) {
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+	defer func() { c.recordLoad(data, err) }()
 
-	kind := c.params
-	err := c.parser.Decode(kind.Type, data, resp)
+	resp, config, err := c.resolve(data, c.group.Load())
+	if err != nil || config == nil {
+		return err
+	}
+	return c.apply(&snapshot{manager: resp, config: config, hash: utils.Hash(data)}, c.notify.Swap(false))
+}
+
+// resolve runs data through the decoding, the missing key policy, the extends and the validation of the reloads,
+// sharing the decoding with the group if any. The config is nil if the current one is kept.
+func (c *configMonitor) resolve(data []byte, g *decodeGroup) (parser.ConfigManager, interface{}, error) {
+	var resp parser.ConfigManager
+	var err error
+	if g != nil {
+		// fall back to decoding the key alone, so that the invalid keys of the other monitors do not fail it
+		if resp, err = g.decode(c, data); err != nil {
+			resp, err = c.decode(data, []string{c.key})
+		}
+	} else {
+		resp, err = c.decode(data, []string{c.key})
+	}
 	if err != nil {
-		klog.Errorf("[local] failed to parse the config file: %v\n", err)
-		return
+		return nil, nil, c.decodeError(err)
+	}
+
+	config := resp.GetConfig(c.key)
+	if config == nil {
+		if config, err = c.missingKey(resp); config == nil {
+			return nil, nil, err
+		}
 	}
 
-	c.config = resp.GetConfig(c.key)
-	if c.config == nil {
+	config, err = resolveExtends(resp, c.key, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if v, ok := config.(parser.Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("invalid config of key %s, keep the current one: %w", c.key, err)
+		}
+	}
+	if err := c.validate(config); err != nil {
+		return nil, nil, err
+	}
+	return resp, config, nil
+}
+
+// missingKey returns the config to apply when the key is missing from the file according to the policy,
+// nil to keep the current one.
+func (c *configMonitor) missingKey(resp parser.ConfigManager) (interface{}, error) {
+	switch c.missingKeyPolicy {
+	case utils.MissingKeyFail:
+		return nil, fmt.Errorf("key %s not found in the config file, keep the current config", c.key)
+	case utils.MissingKeyKeep:
+		klog.Warnf("[local] not matching key found, keep the current config. current key: %v\n", c.key)
+		return nil, nil
+	}
+	d, ok := resp.(parser.ConfigDefaulter)
+	if !ok {
 		klog.Warnf("[local] not matching key found, skip. current key: %v\n", c.key)
-		return
+		return nil, nil
 	}
+	klog.Warnf("[local] not matching key found, restore the defaults. current key: %v\n", c.key)
+	return d.DefaultConfig(), nil
+}
 
-	if len(c.callbacks) > 0 {
-		for key, callback := range c.callbacks {
-			if callback == nil {
-				c.DeregisterCallback(key) // When encountering Nil's callback function, directly cancel it here.
-				klog.Warnf("[local] filewatcher callback %v is nil, deregister it", key)
+// apply makes s the current config as a new revision and invokes the callbacks, it must be called under reloadLock.
+// Nothing is done if the config is not changed, unless force is true.
+func (c *configMonitor) apply(s *snapshot, force bool) error {
+	var old interface{}
+	if prev, ok := c.current.Load().(*snapshot); ok {
+		if !force && reflect.DeepEqual(prev.config, s.config) {
+			klog.Debugf("[local] config of key %s is not changed, skip the callbacks\n", c.key)
+			return nil
+		}
+		old = prev.config
+	}
+	c.revision++
+	s.revision, s.time = c.revision, time.Now()
+	c.current.Store(s)
+	c.record(s)
+
+	diff := DiffConfigs(old, s.config)
+	if old != nil && len(diff) > 0 {
+		klog.Infof("[local] config of key %s changed: %v\n", c.key, diff)
+	}
+	for _, e := range c.callbackList() {
+		switch {
+		case e.change != nil:
+			e.change(old, s.config, diff)
+		case e.callback != nil:
+			if force || e.path == nil || diff.Affects(e.path...) {
+				e.callback()
+			}
+		default:
+			c.DeregisterCallback(e.id) // When encountering Nil's callback function, directly cancel it here.
+			klog.Warnf("[local] filewatcher callback %v is nil, deregister it", e.id)
+		}
+	}
+	return nil
+}
+
+// decodeError returns the DecodeError of err with the positions in the files.
+// The data of layered watchers is merged from several files, so the invalid values are located in the files they come from.
+func (c *configMonitor) decodeError(err error) *parser.DecodeError {
+	_, _, params := c.decoding()
+	file := c.fileWatcher.FilePath()
+	l, ok := c.fileWatcher.(layeredWatcher)
+	layered := ok && l.Layered()
+
+	var errs parser.ValidationErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			if !layered {
+				e.File = file
 				continue
 			}
-			callback()
+			e.Position = parser.Position{File: c.origin(parser.PathKeys(e.Path)...)}
+			if content, err := os.ReadFile(e.File); err == nil {
+				if pos, ok := parser.Locate(params.Type, content, e.Path); ok {
+					pos.File = e.File
+					e.Position = pos
+				}
+			}
 		}
 	}
-	klog.Infof("[local] config parse and update complete \n")
+
+	var de *parser.DecodeError
+	if errors.As(err, &de) {
+		copied := *de
+		copied.File = file
+		return &copied
+	}
+	de = &parser.DecodeError{Position: parser.Position{File: file}, Err: err}
+	if len(errs) > 0 {
+		de.Position, de.Path = errs[0].Position, errs[0].Path
+	}
+	return de
+}
+
+// decode decodes data into a new manager, the running config must not be touched before it is validated.
+// Only the keys which the configs of keys depend on are decoded if the parser supports it.
+func (c *configMonitor) decode(data []byte, keys []string) (parser.ConfigManager, error) {
+	manager, p, params := c.decoding()
+	resp := reflect.New(reflect.TypeOf(manager).Elem()).Interface().(parser.ConfigManager)
+	var err error
+	if d, ok := p.(parser.KeyDecoder); ok {
+		err = d.DecodeKeys(params.Type, data, keys, resp)
+	} else {
+		err = p.Decode(params.Type, data, resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// resolveExtends merges the chain of configs that config of key extends, from the root to config itself.
+// If the manager merges wildcard keys, the parents are looked up by their exact keys,
+// and the wildcard keys matching key are merged once below the root.
+func resolveExtends(manager parser.ConfigManager, key string, config interface{}) (interface{}, error) {
+	e, ok := config.(parser.Extender)
+	if !ok || e.ExtendsKey() == "" {
+		return config, nil
+	}
+
+	lookup, own := manager.GetConfig, config
+	var base interface{}
+	if w, ok := manager.(parser.WildcardManager); ok {
+		lookup = w.ExactConfig
+		base, own = w.WildcardConfig(key), w.ExactConfig(key)
+	}
+
+	chain, _, err := extendsChain(lookup, key, e)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := base
+	for i := len(chain) - 1; i > 0; i-- {
+		resolved = chain[i].Inherit(resolved)
+	}
+	if e, ok := own.(parser.Extender); ok {
+		resolved = e.Inherit(resolved)
+	}
+	return resolved, nil
+}
+
+// extendsChain returns the configs that e, the config of key, extends and their keys, from e itself to the root.
+func extendsChain(lookup func(key string) interface{}, key string, e parser.Extender) ([]parser.Extender, []string, error) {
+	chain := []parser.Extender{e}
+	keys := []string{key}
+	for parent := e.ExtendsKey(); parent != ""; parent = e.ExtendsKey() {
+		for _, k := range keys {
+			if k == parent {
+				return nil, nil, fmt.Errorf("cyclic extends of key %s: %s -> %s", key, strings.Join(keys, " -> "), parent)
+			}
+		}
+		pc := lookup(parent)
+		if pc == nil {
+			return nil, nil, fmt.Errorf("key %s extends %s, which is not found", keys[len(keys)-1], parent)
+		}
+		var ok bool
+		if e, ok = pc.(parser.Extender); !ok {
+			return nil, nil, fmt.Errorf("key %s extends %s, whose config type %T can not be inherited", keys[len(keys)-1], parent, pc)
+		}
+		chain = append(chain, e)
+		keys = append(keys, parent)
+	}
+	return chain, keys, nil
+}
+
+// sourceKeys returns the keys whose values make up the config of key, from the most specific to the least specific:
+// key itself, the keys it extends, then the wildcard keys matching it, in the same way as resolveExtends.
+func sourceKeys(manager parser.ConfigManager, key string) []string {
+	keys := []string{key}
+	lookup := manager.GetConfig
+	w, wildcard := manager.(parser.WildcardManager)
+	if wildcard {
+		lookup = w.ExactConfig
+	}
+	if e, ok := manager.GetConfig(key).(parser.Extender); ok {
+		if _, chain, err := extendsChain(lookup, key, e); err == nil {
+			keys = chain
+		}
+	}
+	if wildcard {
+		patterns := w.WildcardKeys(key)
+		for i := len(patterns) - 1; i >= 0; i-- {
+			keys = append(keys, patterns[i])
+		}
+	}
+	return keys
+}
+
+// logReloadError logs the error of a reload, listing each invalid field on its own line with its position.
+func (c *configMonitor) logReloadError(err error) {
+	var errs parser.ValidationErrors
+	if !errors.As(err, &errs) {
+		var de *parser.DecodeError
+		if errors.As(err, &de) {
+			if de.Snippet == "" {
+				klog.Errorf("[local] failed to parse the config file: %v\n", err)
+				return
+			}
+			klog.Errorf("[local] failed to parse the config file: %v\n%s\n", err, indent(de.Excerpt()))
+			return
+		}
+		klog.Errorf("[local] %v\n", err)
+		return
+	}
+	var sb strings.Builder
+	for _, e := range errs {
+		sb.WriteString("\n\t")
+		if pos := e.Position.String(); pos != "" {
+			sb.WriteString(pos)
+			sb.WriteString(": ")
+		}
+		sb.WriteString(e.Error())
+		if e.Snippet != "" {
+			sb.WriteString("\n")
+			sb.WriteString(indent(e.Excerpt()))
+		}
+	}
+	klog.Errorf("[local] reload of key %s rejected, %d invalid field(s):%s\n", c.key, len(errs), sb.String())
+}
+
+// indent indents each line of s by two tabs.
+func indent(s string) string {
+	return "\t\t" + strings.ReplaceAll(s, "\n", "\n\t\t")
 }
@@ -15,11 +15,15 @@
 package monitor
 
 import (
+	"errors"
+	"os"
+	"path"
 	"testing"
 
 	"github.com/kitex-contrib/config-file/filewatcher"
 	"github.com/kitex-contrib/config-file/mock"
 	"github.com/kitex-contrib/config-file/parser"
+	"github.com/kitex-contrib/config-file/utils"
 )
 
 const (
@@ -225,3 +229,410 @@ func testProcess(filepath string, mapKey []string, t *testing.T) {
 
 	fw.StopWatching()
 }
+
+func TestReloadInvalidConfig(t *testing.T) {
+	m := mock.NewMockFileWatcher()
+	cm, err := NewConfigMonitor("Test1", m)
+	if err != nil {
+		t.Errorf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ServerFileManager{})
+	c := cm.(*configMonitor)
+
+	if err := c.reload([]byte(`{"Test1": {"limit": {"qps_limit": 200}}}`)); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	if err := c.reload([]byte(`{"Test1": {"limit": {"qps_limit": -1}}}`)); err == nil {
+		t.Errorf("reload() should reject negative qps_limit")
+	}
+	if got := cm.Config().(*parser.ServerFileConfig).Limit.QPSLimit; got != 200 {
+		t.Errorf("the last valid config should be kept, got qps_limit %v", got)
+	}
+}
+
+func TestReloadRemovedKey(t *testing.T) {
+	m := mock.NewMockFileWatcher()
+	cm, err := NewConfigMonitor("Client/Service", m)
+	if err != nil {
+		t.Errorf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ClientFileManager{})
+	c := cm.(*configMonitor)
+
+	old := []byte(`{"Client/Service": {"timeout": {"Echo": {"rpc_timeout_ms": 100}, "Pay": {"rpc_timeout_ms": 200}}}}`)
+	if err := c.reload(old); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	config := cm.Config().(*parser.ClientFileConfig)
+
+	// the method removed from the file must disappear
+	if err := c.reload([]byte(`{"Client/Service": {"timeout": {"Echo": {"rpc_timeout_ms": 100}}}}`)); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	if _, ok := cm.Config().(*parser.ClientFileConfig).Timeout["Pay"]; ok {
+		t.Errorf("removed method Pay should not be in the config")
+	}
+	// the previous snapshot is never modified
+	if len(config.Timeout) != 2 {
+		t.Errorf("previous config should not be modified, got %v", config.Timeout)
+	}
+
+	// the key removed from the file restores the defaults
+	if err := c.reload([]byte(`{"Other/Service": {}}`)); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	if got := cm.Config().(*parser.ClientFileConfig); len(got.Timeout) != 0 {
+		t.Errorf("removed key should restore the defaults, got %v", got.Timeout)
+	}
+}
+
+func TestReloadRemovedKeyPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy  utils.MissingKeyPolicy
+		wantErr bool
+		want    int64
+	}{
+		{policy: utils.MissingKeyReset, want: 0},
+		{policy: utils.MissingKeyKeep, want: 100},
+		{policy: utils.MissingKeyFail, wantErr: true, want: 100},
+	} {
+		cm, err := NewConfigMonitor("Test1", mock.NewMockFileWatcher(), func(o *utils.Options) { o.MissingKey = tt.policy })
+		if err != nil {
+			t.Fatalf("NewConfigMonitor() error = %v", err)
+		}
+		cm.SetManager(&parser.ServerFileManager{})
+		c := cm.(*configMonitor)
+
+		if err := c.reload([]byte(`{"Test1": {"limit": {"qps_limit": 100}}}`)); err != nil {
+			t.Errorf("reload() error = %v", err)
+		}
+		if err := c.reload([]byte(`{"Other": {}}`)); (err != nil) != tt.wantErr {
+			t.Errorf("policy %v: reload() error = %v, want error %v", tt.policy, err, tt.wantErr)
+		}
+		if got := cm.Config().(*parser.ServerFileConfig).Limit.QPSLimit; got != tt.want {
+			t.Errorf("policy %v: got qps_limit %d, want %d", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestReloadExtends(t *testing.T) {
+	m := mock.NewMockFileWatcher()
+	cm, err := NewConfigMonitor("Client/Service", m)
+	if err != nil {
+		t.Errorf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ClientFileManager{})
+	c := cm.(*configMonitor)
+
+	data := []byte(`{
+		"templates/base": {"timeout": {"*": {"rpc_timeout_ms": 1000}, "Echo": {"rpc_timeout_ms": 1000}}},
+		"templates/standard-rpc": {"extends": "templates/base", "timeout": {"Echo": {"rpc_timeout_ms": 2000}}},
+		"Client/Service": {"extends": "templates/standard-rpc", "timeout": {"Pay": {"rpc_timeout_ms": 3000}}}
+	}`)
+	if err := c.reload(data); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	config := cm.Config().(*parser.ClientFileConfig)
+	for method, want := range map[string]int{"*": 1000, "Echo": 2000, "Pay": 3000} {
+		if got := config.Timeout[method].RPCTimeoutMS; got != want {
+			t.Errorf("rpc_timeout_ms of %s should be %v, got %v", method, want, got)
+		}
+	}
+
+	// a policy enabled by the parent is disabled by the child
+	data = []byte(`{
+		"templates/standard-rpc": {
+			"retry": {"*": {"enable": true, "type": 0, "failure_policy": {"stop_policy": {"max_retry_times": 2}}}},
+			"circuitbreaker": {"*": {"enable": true, "err_rate": 0.5}}
+		},
+		"Client/Service": {
+			"extends": "templates/standard-rpc",
+			"retry": {"*": {"enable": false}},
+			"circuitbreaker": {"*": {"enable": false}}
+		}
+	}`)
+	if err := c.reload(data); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	config = cm.Config().(*parser.ClientFileConfig)
+	if policy := config.Retry["*"]; policy.Enable || policy.FailurePolicy.StopPolicy.MaxRetryTimes != 2 {
+		t.Errorf("retry should be disabled with max_retry_times 2, got %+v", *policy)
+	}
+	if cb := config.Circuitbreaker["*"]; cb.Enable || cb.ErrRate != 0.5 {
+		t.Errorf("circuitbreaker should be disabled with err_rate 0.5, got %+v", *cb)
+	}
+
+	cyclic := []byte(`{
+		"templates/a": {"extends": "templates/b"},
+		"templates/b": {"extends": "templates/a"},
+		"Client/Service": {"extends": "templates/a"}
+	}`)
+	if err := c.reload(cyclic); err == nil {
+		t.Errorf("reload() should reject cyclic extends")
+	}
+	if err := c.reload([]byte(`{"Client/Service": {"extends": "templates/unknown"}}`)); err == nil {
+		t.Errorf("reload() should reject unknown extends")
+	}
+}
+
+func TestReloadExtendsWildcard(t *testing.T) {
+	m := mock.NewMockFileWatcher()
+	cm, err := NewConfigMonitor("c/s", m)
+	if err != nil {
+		t.Errorf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ClientFileManager{})
+	c := cm.(*configMonitor)
+
+	// the wildcard keys are merged below the template, not into it
+	data := []byte(`{
+		"*": {"timeout": {"*": {"rpc_timeout_ms": 1000, "conn_timeout_ms": 50}}},
+		"templates/std": {"timeout": {"*": {"rpc_timeout_ms": 500}}},
+		"c/s": {"extends": "templates/std"}
+	}`)
+	if err := c.reload(data); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	timeout := cm.Config().(*parser.ClientFileConfig).Timeout["*"]
+	if timeout.RPCTimeoutMS != 500 || timeout.ConnTimeoutMS != 50 {
+		t.Errorf("timeout should be {500 50}, got %+v", *timeout)
+	}
+
+	// the extends of a wildcard key is not applied to the template again
+	data = []byte(`{
+		"*": {"extends": "templates/std"},
+		"templates/std": {"timeout": {"*": {"rpc_timeout_ms": 500}}}
+	}`)
+	if err := c.reload(data); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	if got := cm.Config().(*parser.ClientFileConfig).Timeout["*"].RPCTimeoutMS; got != 500 {
+		t.Errorf("rpc_timeout_ms should be 500, got %v", got)
+	}
+}
+
+// countingParser counts the decoding of the whole file.
+type countingParser struct {
+	count int
+}
+
+func (p *countingParser) Decode(kind parser.ConfigType, data []byte, config interface{}) error {
+	p.count++
+	return parser.DefaultConfigParser().Decode(kind, data, config)
+}
+
+func TestSharedDecode(t *testing.T) {
+	fw, err := filewatcher.NewFileWatcher(filepath)
+	if err != nil {
+		t.Fatalf("NewFileWatcher() error = %v", err)
+	}
+	p := &countingParser{}
+	calls := map[string]int{}
+	var monitors []*configMonitor
+	for _, key := range []string{"Test1", "Test2"} {
+		key := key
+		cm, err := NewConfigMonitor(key, fw, func(o *utils.Options) { o.Parser = p })
+		if err != nil {
+			t.Fatalf("NewConfigMonitor() error = %v", err)
+		}
+		cm.SetManager(&parser.ServerFileManager{})
+		cm.RegisterCallback(func() { calls[key]++ })
+		if err := cm.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		defer cm.Stop()
+		monitors = append(monitors, cm.(*configMonitor))
+	}
+
+	p.count = 0
+	data := []byte(`{"Test1": {"limit": {"qps_limit": 200}}, "Test2": {"limit": {"qps_limit": 200}}}`)
+	for _, c := range monitors {
+		if err := c.reload(data); err != nil {
+			t.Errorf("reload() error = %v", err)
+		}
+	}
+	if p.count != 1 {
+		t.Errorf("the file should be decoded once, got %d", p.count)
+	}
+
+	// only the monitor of the changed key invokes its callbacks
+	calls = map[string]int{}
+	data = []byte(`{"Test1": {"limit": {"qps_limit": 200}}, "Test2": {"limit": {"qps_limit": 100}}}`)
+	for _, c := range monitors {
+		if err := c.reload(data); err != nil {
+			t.Errorf("reload() error = %v", err)
+		}
+	}
+	if calls["Test1"] != 0 || calls["Test2"] != 1 {
+		t.Errorf("callbacks of unchanged key should be skipped, got %v", calls)
+	}
+}
+
+func TestSharedDecodeFallback(t *testing.T) {
+	fw, err := filewatcher.NewFileWatcher(filepath)
+	if err != nil {
+		t.Fatalf("NewFileWatcher() error = %v", err)
+	}
+	var monitors []*configMonitor
+	for _, key := range []string{"Test1", "Test2"} {
+		cm, err := NewConfigMonitor(key, fw)
+		if err != nil {
+			t.Fatalf("NewConfigMonitor() error = %v", err)
+		}
+		cm.SetManager(&parser.ServerFileManager{})
+		if err := cm.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		defer cm.Stop()
+		monitors = append(monitors, cm.(*configMonitor))
+	}
+	if monitors[0].group.Load() == nil || monitors[0].group.Load() != monitors[1].group.Load() {
+		t.Fatalf("monitors of the same file should share the decoding")
+	}
+
+	// the invalid key of a monitor does not fail the other one
+	data := []byte(`{"Test1": {"limit": {"qps_limit": 300}}, "Test2": {"limit": {"qps_limit": "invalid"}}}`)
+	if err := monitors[0].reload(data); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	if err := monitors[1].reload(data); err == nil {
+		t.Errorf("reload() should reject invalid qps_limit")
+	}
+}
+
+func TestOriginWithoutLayers(t *testing.T) {
+	cm, err := NewConfigMonitor("Test1", mock.NewMockFileWatcher())
+	if err != nil {
+		t.Fatalf("NewConfigMonitor() error = %v", err)
+	}
+	// the watchers without Origin load every value from the watched file
+	if got := cm.Origin("limit", "qps_limit"); got != "test" {
+		t.Errorf("Origin() = %q, want the watched file", got)
+	}
+}
+
+func TestOriginInherited(t *testing.T) {
+	dir := t.TempDir()
+	base, overlay := path.Join(dir, "base.json"), path.Join(dir, "prod.json")
+	if err := os.WriteFile(base, []byte(`{
+		"*": {"timeout": {"*": {"conn_timeout_ms": 50}}},
+		"templates/std": {"timeout": {"*": {"rpc_timeout_ms": 1000}}},
+		"c/s": {"extends": "templates/std", "timeout": {"Pay": {"rpc_timeout_ms": 3000}}}
+	}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overlay, []byte(`{"templates/std": {"timeout": {"*": {"rpc_timeout_ms": 500}}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fw, err := filewatcher.NewLayeredFileWatcher(parser.JSON, base, overlay)
+	if err != nil {
+		t.Fatalf("NewLayeredFileWatcher() error = %v", err)
+	}
+	cm, err := NewConfigMonitor("c/s", fw)
+	if err != nil {
+		t.Fatalf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ClientFileManager{})
+	if err := cm.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer cm.Stop()
+
+	// the values are looked up in the key, the keys it extends, then the wildcard keys
+	for _, tt := range []struct {
+		path []string
+		want string
+	}{
+		{[]string{"timeout", "Pay", "rpc_timeout_ms"}, base},
+		{[]string{"timeout", "*", "rpc_timeout_ms"}, overlay},
+		{[]string{"timeout", "*", "conn_timeout_ms"}, base},
+		{[]string{"retry"}, ""},
+	} {
+		if got := cm.Origin(tt.path...); got != tt.want {
+			t.Errorf("Origin(%v) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestReloadDecodeError(t *testing.T) {
+	m := mock.NewMockFileWatcher()
+	cm, err := NewConfigMonitor("Test1", m)
+	if err != nil {
+		t.Errorf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ServerFileManager{})
+	c := cm.(*configMonitor)
+
+	var de *parser.DecodeError
+	err = c.reload([]byte("{\n  \"Test1\": {\"limit\": {\"qps_limit\": 100,}}\n}"))
+	if !errors.As(err, &de) || de.File != "test" || de.Line != 2 {
+		t.Errorf("reload() should return the position of the syntax error, got %v", err)
+	}
+}
+
+func TestReloadLayeredDecodeError(t *testing.T) {
+	dir := t.TempDir()
+	base, overlay := path.Join(dir, "base.json"), path.Join(dir, "prod.json")
+	if err := os.WriteFile(base, []byte(`{"Test1": {"limit": {"qps_limit": 100}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overlay, []byte("{\n  \"Test1\": {\n    \"limit\": {\"connection_limit\": \"invalid\"}\n  }\n}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fw, err := filewatcher.NewLayeredFileWatcher(parser.JSON, base, overlay)
+	if err != nil {
+		t.Fatalf("NewLayeredFileWatcher() error = %v", err)
+	}
+	cm, err := NewConfigMonitor("Test1", fw)
+	if err != nil {
+		t.Errorf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ServerFileManager{})
+	if err := cm.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer cm.Stop()
+
+	// the merged data of the layers is located in the overlay
+	var de *parser.DecodeError
+	err = cm.(*configMonitor).reload([]byte(`{"Test1":{"limit":{"connection_limit":"invalid","qps_limit":100}}}`))
+	if !errors.As(err, &de) || de.File != overlay || de.Line != 3 || de.Path != "$.Test1.limit.connection_limit" {
+		t.Errorf("reload() should locate the invalid value in the overlay, got %#v", de)
+	}
+}
+
+func TestTyped(t *testing.T) {
+	typed, err := NewTypedConfigMonitor[*parser.ServerFileConfig]("Test1", mock.NewMockFileWatcher(), &parser.ServerFileManager{})
+	if err != nil {
+		t.Fatalf("NewTypedConfigMonitor() error = %v", err)
+	}
+	if typed.Current() != nil {
+		t.Errorf("Current() should be nil before the first update")
+	}
+
+	var changes [][2]int64
+	typed.OnChange(func(old, new *parser.ServerFileConfig) {
+		var prev int64
+		if old != nil {
+			prev = old.Limit.QPSLimit
+		}
+		changes = append(changes, [2]int64{prev, new.Limit.QPSLimit})
+	})
+	c := typed.ConfigMonitor.(*configMonitor)
+	for _, data := range []string{
+		`{"Test1": {"limit": {"qps_limit": 100}}}`,
+		`{"Test1": {"limit": {"qps_limit": 200}}}`,
+	} {
+		if err := c.reload([]byte(data)); err != nil {
+			t.Errorf("reload() error = %v", err)
+		}
+	}
+	if typed.Current().Limit.QPSLimit != 200 || len(changes) != 2 || changes[1] != [2]int64{100, 200} {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	// a mismatched type returns the zero value
+	if NewTyped[*parser.ClientFileConfig](c).Current() != nil {
+		t.Errorf("Current() should be nil for a mismatched type")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/kitex-contrib/config-file/filewatcher"
+	"github.com/kitex-contrib/config-file/parser"
+)
+
+// run with -race
+func TestConcurrentReloadAndRead(t *testing.T) {
+	fw, err := filewatcher.NewFileWatcher(filepath)
+	if err != nil {
+		t.Fatalf("NewFileWatcher() error = %v", err)
+	}
+	var monitors []*configMonitor
+	for _, key := range []string{"Test1", "Test2"} {
+		cm, err := NewConfigMonitor(key, fw)
+		if err != nil {
+			t.Fatalf("NewConfigMonitor() error = %v", err)
+		}
+		cm.SetManager(&parser.ServerFileManager{})
+		if err := cm.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		defer cm.Stop()
+		monitors = append(monitors, cm.(*configMonitor))
+	}
+
+	const rounds = 200
+	var wg sync.WaitGroup
+	for _, c := range monitors {
+		c := c
+		wg.Add(4)
+		go func() { // reloads
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				data := fmt.Sprintf(`{"Test1": {"limit": {"qps_limit": %d}}, "Test2": {"limit": {"qps_limit": %d}}}`, i, i)
+				if err := c.reload([]byte(data)); err != nil {
+					t.Errorf("reload() error = %v", err)
+				}
+			}
+		}()
+		go func() { // the file watcher
+			defer wg.Done()
+			for i := 0; i < rounds/10; i++ {
+				if err := fw.CallOnceAll(); err != nil {
+					t.Errorf("CallOnceAll() error = %v", err)
+				}
+			}
+		}()
+		go func() { // readers
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				if config, ok := c.Config().(*parser.ServerFileConfig); !ok || config.Limit.QPSLimit < 0 {
+					t.Errorf("unexpected config %v", c.Config())
+				}
+				_ = c.CallbackSize()
+				_ = c.WatcherID()
+			}
+		}()
+		go func() { // callbacks registered and deregistered during the reloads
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				id := c.RegisterCallback(func() { _ = c.Config() })
+				c.DeregisterCallback(id)
+			}
+		}()
+	}
+	wg.Wait()
+}
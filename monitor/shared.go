@@ -0,0 +1,140 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/kitex-contrib/config-file/filewatcher"
+	"github.com/kitex-contrib/config-file/parser"
+)
+
+// decodeGroup shares the decoding of a file among the monitors which decode it in the same way,
+// so that the file is decoded once per change rather than once per monitor.
+type decodeGroup struct {
+	id      groupID
+	lock    sync.Mutex
+	keys    map[string]int // key of the monitors -> number of the monitors
+	data    []byte         // the last decoded data
+	decoded bool
+	manager parser.ConfigManager // the result of data, with the keys of all the monitors
+	err     error
+}
+
+// groupID identifies the monitors which decode a file in the same way.
+type groupID struct {
+	watcher  filewatcher.FileWatcher
+	kind     parser.ConfigType
+	manager  reflect.Type
+	parser   interface{} // the parser before wrapped by strict and template, see parserID
+	strict   bool
+	template bool
+}
+
+var (
+	groupsLock sync.Mutex
+	groups     = map[groupID]*decodeGroup{}
+)
+
+// joinGroup adds the monitor to its group, it returns nil if the decoding of the monitor can not be shared,
+// i.e. the watcher or the parser can not be identified, see isPointer and parserID.
+func joinGroup(c *configMonitor) *decodeGroup {
+	c.lock.RLock()
+	base, params, manager := c.baseParser, c.params, c.manager
+	c.lock.RUnlock()
+
+	pid, ok := parserID(base)
+	if !ok || !isPointer(c.fileWatcher) || params == nil {
+		return nil
+	}
+	id := groupID{
+		watcher:  c.fileWatcher,
+		kind:     params.Type,
+		manager:  reflect.TypeOf(manager),
+		parser:   pid,
+		strict:   c.strict,
+		template: c.template,
+	}
+
+	groupsLock.Lock()
+	defer groupsLock.Unlock()
+	g, ok := groups[id]
+	if !ok {
+		g = &decodeGroup{id: id, keys: map[string]int{}}
+		groups[id] = g
+	}
+	g.lock.Lock()
+	g.keys[c.key]++
+	g.decoded = false // decode the keys of the new monitor as well
+	g.lock.Unlock()
+	return g
+}
+
+// leave removes the monitor of key from the group, the group is dropped with its last monitor.
+func (g *decodeGroup) leave(key string) {
+	groupsLock.Lock()
+	defer groupsLock.Unlock()
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.keys[key]--; g.keys[key] <= 0 {
+		delete(g.keys, key)
+	}
+	if len(g.keys) == 0 && groups[g.id] == g {
+		delete(groups, g.id)
+	}
+}
+
+// decode returns the manager decoded from data with the keys of all the monitors in the group,
+// the data is only decoded by the first monitor which receives it.
+func (g *decodeGroup) decode(c *configMonitor, data []byte) (parser.ConfigManager, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.decoded && bytes.Equal(g.data, data) {
+		return g.manager, g.err
+	}
+
+	keys := make([]string, 0, len(g.keys))
+	for key := range g.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	g.manager, g.err = c.decode(data, keys)
+	g.data, g.decoded = data, true
+	return g.manager, g.err
+}
+
+// parserID identifies a parser by its pointer, or by its type if it has no state, such as parser.Parser.
+func parserID(p parser.ConfigParser) (interface{}, bool) {
+	t := reflect.TypeOf(p)
+	switch {
+	case t == nil:
+		return nil, false
+	case t.Kind() == reflect.Ptr && t.Elem().Size() == 0, t.Size() == 0:
+		return t, true
+	case isPointer(p):
+		return p, true
+	}
+	return nil, false
+}
+
+// isPointer reports whether v is a pointer which identifies its value.
+func isPointer(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	return t != nil && t.Kind() == reflect.Ptr && t.Elem().Size() > 0
+}
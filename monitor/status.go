@@ -0,0 +1,119 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kitex-contrib/config-file/parser"
+	"github.com/kitex-contrib/config-file/utils"
+)
+
+// Status is the state of a ConfigMonitor, for introspection.
+type Status struct {
+	Key          string
+	FilePath     string
+	Type         parser.ConfigType
+	LastLoad     time.Time // when the file was last reloaded successfully, zero if never
+	Hash         string    // sha256 of the file content last reloaded successfully
+	Revision     int64     // id of the current config in History, 0 if none
+	LastError    error     // error of the last reload, nil if it succeeded
+	CallbackSize int
+}
+
+// loadResult is the result of the reloads, it is replaced as a whole.
+type loadResult struct {
+	time time.Time // of the last successful reload
+	hash string    // of the last successful reload
+	err  error     // of the last reload
+}
+
+// Status returns the state of the monitor.
+func (c *configMonitor) Status() Status {
+	_, _, params := c.decoding()
+	status := Status{
+		Key:          c.key,
+		FilePath:     c.fileWatcher.FilePath(),
+		Type:         params.Type,
+		CallbackSize: c.CallbackSize(),
+	}
+	if s, ok := c.current.Load().(*snapshot); ok {
+		status.Revision = s.revision
+	}
+	if r := c.loaded.Load(); r != nil {
+		status.LastLoad, status.Hash, status.LastError = r.time, r.hash, r.err
+	}
+	return status
+}
+
+// recordLoad records the result of a reload of data, it must be called under reloadLock.
+func (c *configMonitor) recordLoad(data []byte, err error) {
+	r := &loadResult{err: err}
+	if err == nil {
+		r.time, r.hash = time.Now(), utils.Hash(data)
+	} else if last := c.loaded.Load(); last != nil {
+		r.time, r.hash = last.time, last.hash
+	}
+	c.loaded.Store(r)
+}
+
+// registry is the process-wide list of the monitors, see Register.
+var registry struct {
+	lock     sync.RWMutex
+	monitors []ConfigMonitor
+}
+
+// Register adds m to the process-wide registry listed by Monitors, the client and server suites register their monitors
+// on creation. A monitor is removed from the registry when it stops.
+func Register(m ConfigMonitor) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	for _, r := range registry.monitors {
+		if r == m {
+			return
+		}
+	}
+	registry.monitors = append(registry.monitors, m)
+}
+
+// Deregister removes m from the registry.
+func Deregister(m ConfigMonitor) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	for i, r := range registry.monitors {
+		if r == m {
+			registry.monitors = append(registry.monitors[:i:i], registry.monitors[i+1:]...)
+			return
+		}
+	}
+}
+
+// Monitors returns the registered monitors in the order of registration.
+func Monitors() []ConfigMonitor {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+	return append([]ConfigMonitor(nil), registry.monitors...)
+}
+
+// Statuses returns the status of each registered monitor, e.g. to answer what config the process is running.
+func Statuses() []Status {
+	monitors := Monitors()
+	statuses := make([]Status, 0, len(monitors))
+	for _, m := range monitors {
+		statuses = append(statuses, m.Status())
+	}
+	return statuses
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"testing"
+
+	"github.com/kitex-contrib/config-file/mock"
+	"github.com/kitex-contrib/config-file/parser"
+	"github.com/kitex-contrib/config-file/utils"
+)
+
+func TestStatus(t *testing.T) {
+	cm, err := NewConfigMonitor("Test1", mock.NewMockFileWatcher())
+	if err != nil {
+		t.Fatalf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ServerFileManager{})
+	c := cm.(*configMonitor)
+	cm.RegisterCallback(func() {})
+
+	if status := cm.Status(); status.Revision != 0 || !status.LastLoad.IsZero() || status.Type != parser.JSON {
+		t.Errorf("unexpected status before the first reload %+v", status)
+	}
+
+	data := []byte(`{"Test1": {"limit": {"qps_limit": 100}}}`)
+	if err := c.reload(data); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	if err := c.reload([]byte(`{"Test1": {"limit": {"qps_limit": -1}}}`)); err == nil {
+		t.Errorf("reload() should reject negative qps_limit")
+	}
+	status := cm.Status()
+	if status.Key != "Test1" || status.FilePath != "test" || status.Revision != 1 || status.CallbackSize != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if status.LastError == nil || status.Hash != utils.Hash(data) || status.LastLoad.IsZero() {
+		t.Errorf("the status should keep the last successful reload with the error, got %+v", status)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	cm, err := NewConfigMonitor("Test1", mock.NewMockFileWatcher())
+	if err != nil {
+		t.Fatalf("NewConfigMonitor() error = %v", err)
+	}
+	Register(cm)
+	Register(cm)
+	if monitors := Monitors(); len(monitors) != 1 || monitors[0] != cm || len(Statuses()) != 1 {
+		t.Errorf("unexpected monitors %v", monitors)
+	}
+	cm.Stop()
+	if monitors := Monitors(); len(monitors) != 0 {
+		t.Errorf("the stopped monitor should be removed, got %v", monitors)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/kitex-contrib/config-file/filewatcher"
+	"github.com/kitex-contrib/config-file/parser"
+	"github.com/kitex-contrib/config-file/utils"
+)
+
+// Typed is a ConfigMonitor whose configs are of type T, e.g. Typed[*parser.ClientFileConfig].
+type Typed[T any] struct {
+	ConfigMonitor
+}
+
+// NewTyped wraps m, whose manager must return configs of type T.
+func NewTyped[T any](m ConfigMonitor) *Typed[T] {
+	return &Typed[T]{ConfigMonitor: m}
+}
+
+// NewTypedConfigMonitor init a typed monitor for the config file, with the manager decoding the file.
+func NewTypedConfigMonitor[T any](key string, watcher filewatcher.FileWatcher, manager parser.ConfigManager, opts ...utils.Option) (*Typed[T], error) {
+	m, err := NewConfigMonitor(key, watcher, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m.SetManager(manager)
+	return NewTyped[T](m), nil
+}
+
+// Current returns the current config, or the zero value of T if there is none or it is not of type T.
+func (t *Typed[T]) Current() T {
+	config, ok := t.ConfigMonitor.Config().(T)
+	if !ok && t.ConfigMonitor.Config() != nil {
+		// the manager does not match T, which is a bug of the caller
+		klog.Errorf("[local] Invalid config type: %T, expected %T", t.ConfigMonitor.Config(), config)
+	}
+	return config
+}
+
+// OnChange registers a callback invoked with the previous and the current configs after each update,
+// the previous one is the zero value of T for the first update. It returns the key for DeregisterCallback.
+func (t *Typed[T]) OnChange(callback func(old, new T)) int64 {
+	return t.OnDiff(func(old, new T, _ Diff) { callback(old, new) })
+}
+
+// OnDiff is OnChange with the changes between the previous and the current configs, see DiffConfigs.
+func (t *Typed[T]) OnDiff(callback func(old, new T, diff Diff)) int64 {
+	return t.RegisterChangeCallback(func(old, new interface{}, diff Diff) {
+		prev, _ := old.(T)
+		current, _ := new.(T)
+		callback(prev, current, diff)
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"fmt"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+)
+
+// validatorEntry is an element of the validator list, which is never modified once stored.
+type validatorEntry struct {
+	id       int64
+	validate func(config interface{}) error
+}
+
+// RegisterValidator add a validator of the new configs, which runs before the config is replaced and the callbacks are invoked.
+// The reload is rejected and the current config is kept if it returns an error, return key for DeregisterValidator
+func (c *configMonitor) RegisterValidator(validate func(config interface{}) error) int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := c.counter.Add(1)
+	list := c.validatorList()
+	updated := make([]validatorEntry, len(list), len(list)+1)
+	copy(updated, list)
+	c.validators.Store(append(updated, validatorEntry{id: key, validate: validate}))
+	return key
+}
+
+// DeregisterValidator remove validator function.
+func (c *configMonitor) DeregisterValidator(key int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	list := c.validatorList()
+	updated := make([]validatorEntry, 0, len(list))
+	for _, e := range list {
+		if e.id != key {
+			updated = append(updated, e)
+		}
+	}
+	if len(updated) == len(list) {
+		klog.Warnf("[local] ConfigMonitor validator %v not registered", key)
+		return
+	}
+	c.validators.Store(updated)
+}
+
+// validatorList returns the current validators, which must not be modified.
+func (c *configMonitor) validatorList() []validatorEntry {
+	list, _ := c.validators.Load().([]validatorEntry)
+	return list
+}
+
+// validate runs the registered validators in order, stopping at the first error.
+func (c *configMonitor) validate(config interface{}) error {
+	for _, e := range c.validatorList() {
+		if err := e.validate(config); err != nil {
+			return fmt.Errorf("config of key %s rejected by validator, keep the current one: %w", c.key, err)
+		}
+	}
+	return nil
+}
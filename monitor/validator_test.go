@@ -0,0 +1,64 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kitex-contrib/config-file/mock"
+	"github.com/kitex-contrib/config-file/parser"
+)
+
+func TestRegisterValidator(t *testing.T) {
+	cm, err := NewConfigMonitor("Test1", mock.NewMockFileWatcher())
+	if err != nil {
+		t.Fatalf("NewConfigMonitor() error = %v", err)
+	}
+	cm.SetManager(&parser.ServerFileManager{})
+	c := cm.(*configMonitor)
+
+	errTooHigh := errors.New("qps_limit must not be above 5000")
+	key := cm.RegisterValidator(func(config interface{}) error {
+		if config.(*parser.ServerFileConfig).Limit.QPSLimit > 5000 {
+			return errTooHigh
+		}
+		return nil
+	})
+	called := 0
+	cm.RegisterCallback(func() { called++ })
+
+	reload := func(qps int) error {
+		return c.reload([]byte(fmt.Sprintf(`{"Test1": {"limit": {"qps_limit": %d}}}`, qps)))
+	}
+	if err := reload(100); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	if err := reload(6000); !errors.Is(err, errTooHigh) {
+		t.Errorf("reload() should be rejected by the validator, got %v", err)
+	}
+	if got := cm.Config().(*parser.ServerFileConfig).Limit.QPSLimit; got != 100 || called != 1 {
+		t.Errorf("the rejected config should not be applied, got qps_limit %d, %d callbacks", got, called)
+	}
+
+	cm.DeregisterValidator(key)
+	if err := reload(6000); err != nil {
+		t.Errorf("reload() error = %v", err)
+	}
+	if got := cm.Config().(*parser.ServerFileConfig).Limit.QPSLimit; got != 6000 || called != 2 {
+		t.Errorf("the config should be applied without the validator, got qps_limit %d, %d callbacks", got, called)
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"encoding"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/bytedance/sonic"
+)
+
+var (
+	decoderCache sync.Map // reflect.Type -> decoderFunc
+
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decoderFunc sets v to the value of a generic document.
+type decoderFunc func(doc interface{}, v reflect.Value) error
+
+// decodeDocument decodes a generic document, as unmarshalled into an interface{}, into config like encoding/json,
+// so that the document which has been validated is not parsed again.
+func decodeDocument(doc, config interface{}) error {
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(config)}
+	}
+	return decoderOf(v.Type().Elem())(doc, v.Elem())
+}
+
+// decoderOf returns the cached decoder of t, which follows the json tags.
+// The types decoding themselves and the values of unexpected kinds are decoded by sonic instead.
+func decoderOf(t reflect.Type) decoderFunc {
+	if dec, ok := decoderCache.Load(t); ok {
+		return dec.(decoderFunc)
+	}
+	// a recursive type gets the decoder being built through the indirection, like encoding/json
+	var (
+		wg  sync.WaitGroup
+		dec decoderFunc
+	)
+	wg.Add(1)
+	pending, loaded := decoderCache.LoadOrStore(t, decoderFunc(func(doc interface{}, v reflect.Value) error {
+		wg.Wait()
+		return dec(doc, v)
+	}))
+	if loaded {
+		return pending.(decoderFunc)
+	}
+	dec = newDecoder(t)
+	wg.Done()
+	decoderCache.Store(t, dec)
+	return dec
+}
+
+func newDecoder(t reflect.Type) decoderFunc {
+	if pt := reflect.PtrTo(t); pt.Implements(jsonUnmarshalerType) || pt.Implements(textUnmarshalerType) {
+		return withNull(unmarshalValue)
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return ptrDecoder(t)
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return func(doc interface{}, v reflect.Value) error {
+				if doc == nil {
+					v.Set(reflect.Zero(v.Type()))
+					return nil
+				}
+				v.Set(reflect.ValueOf(doc))
+				return nil
+			}
+		}
+	case reflect.Struct:
+		return structDecoder(t)
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return mapDecoder(t)
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return sliceDecoder(t)
+		}
+	case reflect.String:
+		return withNull(func(doc interface{}, v reflect.Value) error {
+			if s, ok := doc.(string); ok {
+				v.SetString(s)
+				return nil
+			}
+			return unmarshalValue(doc, v)
+		})
+	case reflect.Bool:
+		return withNull(func(doc interface{}, v reflect.Value) error {
+			if b, ok := doc.(bool); ok {
+				v.SetBool(b)
+				return nil
+			}
+			return unmarshalValue(doc, v)
+		})
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return withNull(func(doc interface{}, v reflect.Value) error {
+			if f, ok := toFloat(doc); ok && f == math.Trunc(f) && !v.OverflowInt(int64(f)) {
+				v.SetInt(int64(f))
+				return nil
+			}
+			return unmarshalValue(doc, v)
+		})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return withNull(func(doc interface{}, v reflect.Value) error {
+			if f, ok := toFloat(doc); ok && f == math.Trunc(f) && f >= 0 && !v.OverflowUint(uint64(f)) {
+				v.SetUint(uint64(f))
+				return nil
+			}
+			return unmarshalValue(doc, v)
+		})
+	case reflect.Float32, reflect.Float64:
+		return withNull(func(doc interface{}, v reflect.Value) error {
+			if f, ok := toFloat(doc); ok && !v.OverflowFloat(f) {
+				v.SetFloat(f)
+				return nil
+			}
+			return unmarshalValue(doc, v)
+		})
+	}
+	return withNull(unmarshalValue)
+}
+
+// withNull wraps the decoder of a type whose value is kept by null, like encoding/json.
+func withNull(dec decoderFunc) decoderFunc {
+	return func(doc interface{}, v reflect.Value) error {
+		if doc == nil {
+			return nil
+		}
+		return dec(doc, v)
+	}
+}
+
+func ptrDecoder(t reflect.Type) decoderFunc {
+	elem := decoderOf(t.Elem())
+	return func(doc interface{}, v reflect.Value) error {
+		if doc == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return elem(doc, v.Elem())
+	}
+}
+
+func mapDecoder(t reflect.Type) decoderFunc {
+	elem := decoderOf(t.Elem())
+	convert := t.Key() != reflect.TypeOf("")
+	return func(doc interface{}, v reflect.Value) error {
+		if doc == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			return unmarshalValue(doc, v)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(m)))
+		}
+		value := reflect.New(v.Type().Elem()).Elem() // reused, as SetMapIndex copies it
+		zero := reflect.Zero(value.Type())
+		for k, e := range m {
+			value.Set(zero)
+			if err := elem(e, value); err != nil {
+				return err
+			}
+			key := reflect.ValueOf(k)
+			if convert {
+				key = key.Convert(v.Type().Key())
+			}
+			v.SetMapIndex(key, value)
+		}
+		return nil
+	}
+}
+
+func sliceDecoder(t reflect.Type) decoderFunc {
+	elem := decoderOf(t.Elem())
+	return func(doc interface{}, v reflect.Value) error {
+		if doc == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		a, ok := doc.([]interface{})
+		if !ok {
+			return unmarshalValue(doc, v)
+		}
+		s := reflect.MakeSlice(v.Type(), len(a), len(a))
+		for i, e := range a {
+			if err := elem(e, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+}
+
+// structField is a field of a struct found by its json name, through the untagged embedded structs.
+type structField struct {
+	name  string
+	index []int
+	dec   decoderFunc
+}
+
+// structDecoder sets the fields by their json names, matched exactly or else case-insensitively like encoding/json.
+func structDecoder(t reflect.Type) decoderFunc {
+	var fields []*structField
+	collectFields(t, nil, &fields)
+	byName := make(map[string]*structField, len(fields))
+	for _, f := range fields {
+		if prev, ok := byName[f.name]; !ok || len(f.index) < len(prev.index) {
+			byName[f.name] = f // the shallower field wins
+		}
+	}
+	for _, f := range byName {
+		f.dec = decoderOf(t.FieldByIndex(f.index).Type)
+	}
+	return withNull(func(doc interface{}, v reflect.Value) error {
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			return unmarshalValue(doc, v)
+		}
+		for key, value := range m {
+			f, ok := byName[key]
+			if !ok {
+				for name, byFold := range byName {
+					if strings.EqualFold(name, key) {
+						f, ok = byFold, true
+						break
+					}
+				}
+			}
+			if !ok {
+				continue // unknown fields are reported by the validation
+			}
+			field := v
+			for _, i := range f.index {
+				if field.Kind() == reflect.Ptr {
+					if field.IsNil() {
+						field.Set(reflect.New(field.Type().Elem()))
+					}
+					field = field.Elem()
+				}
+				field = field.Field(i)
+			}
+			if err := f.dec(value, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func collectFields(t reflect.Type, index []int, fields *[]*structField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		fieldIndex := append(index[:len(index):len(index)], i)
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && field.Tag.Get("json") == "" && ft.Kind() == reflect.Struct {
+			collectFields(ft, fieldIndex, fields)
+			continue
+		}
+		if field.IsExported() {
+			*fields = append(*fields, &structField{name: name, index: fieldIndex})
+		}
+	}
+}
+
+// unmarshalValue decodes doc into v by sonic, for the values which the decoders do not handle themselves.
+func unmarshalValue(doc interface{}, v reflect.Value) error {
+	if !v.CanAddr() {
+		return &json.UnmarshalTypeError{Value: describe(doc), Type: v.Type()}
+	}
+	data, err := sonic.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return sonic.Unmarshal(data, v.Addr().Interface())
+}
@@ -15,6 +15,9 @@
 package parser
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/cloudwego/kitex/pkg/circuitbreak"
 	"github.com/cloudwego/kitex/pkg/retry"
 	"github.com/cloudwego/kitex/pkg/rpctimeout"
@@ -22,21 +25,106 @@ import (
 
 // ClientFileConfig is config of a client/service pair
 type ClientFileConfig struct {
-	Timeout        map[string]*rpctimeout.RPCTimeout `mapstructure:"timeout"`        // key: method, "*" for default
-	Retry          map[string]*retry.Policy          `mapstructure:"retry"`          // key: method, "*" for default
-	Circuitbreaker map[string]*circuitbreak.CBConfig `mapstructure:"circuitbreaker"` // key: method
+	Timeout        map[string]*rpctimeout.RPCTimeout `json:"timeout" mapstructure:"timeout"`               // key: method, "*" for default
+	Retry          map[string]*retry.Policy          `json:"retry" mapstructure:"retry"`                   // key: method, "*" for default
+	Circuitbreaker map[string]*circuitbreak.CBConfig `json:"circuitbreaker" mapstructure:"circuitbreaker"` // key: method
+	Extends        string                            `json:"extends" mapstructure:"extends"`               // key of the config to inherit
+
+	doc map[string]interface{} // the fields present in the file, kept if the keys are merged, see documentOf
 }
 
 // ClientFileManager is a map of client/service pairs to ClientFileConfig
 type ClientFileManager map[string]*ClientFileConfig
 
-// GetConfig returns the config from Manager by key
+// GetConfig returns the config from Manager by key.
+// The configs of all the keys matching ClientName/ServiceName are merged field by field,
+// from the least specific to the most specific: *, ClientName/*, */ServiceName, ClientName/ServiceName.
 func (s *ClientFileManager) GetConfig(key string) interface{} {
-	config, exist := (*s)[key]
+	var config *ClientFileConfig
+	for _, pattern := range clientKeyPatterns(key) {
+		if c := (*s)[pattern]; c != nil {
+			config = mergeClientConfig(config, c)
+		}
+	}
 
-	if !exist {
+	if config == nil {
 		return nil
 	}
 
 	return config
 }
+
+// ExactConfig returns the config of the key as written in the file, without the wildcard keys merged.
+func (s *ClientFileManager) ExactConfig(key string) interface{} {
+	if c := (*s)[key]; c != nil {
+		return c
+	}
+	return nil
+}
+
+// WildcardConfig returns the merged configs of the wildcard keys matching the key, without the key itself.
+func (s *ClientFileManager) WildcardConfig(key string) interface{} {
+	var config *ClientFileConfig
+	for _, pattern := range s.WildcardKeys(key) {
+		if c := (*s)[pattern]; c != nil {
+			config = mergeClientConfig(config, c)
+		}
+	}
+
+	if config == nil {
+		return nil
+	}
+
+	return config
+}
+
+// WildcardKeys returns the wildcard keys matching the key, from the least specific to the most specific.
+func (s *ClientFileManager) WildcardKeys(key string) []string {
+	var keys []string
+	for _, pattern := range clientKeyPatterns(key) {
+		if pattern != key {
+			keys = append(keys, pattern)
+		}
+	}
+	return keys
+}
+
+// keepsDocuments reports whether the configs are merged with other keys, by the wildcard keys or extends.
+func (s *ClientFileManager) keepsDocuments() bool {
+	for key, c := range *s {
+		if strings.Contains(key, Wildcard) || c != nil && c.Extends != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ClientFileManager) keepDocuments(doc map[string]interface{}) {
+	for key, c := range *s {
+		if c != nil {
+			c.doc, _ = canonicalDocument(doc[key], reflect.TypeOf(c)).(map[string]interface{})
+		}
+	}
+}
+
+// DefaultConfig returns an empty config, with which the suite restores the defaults of kitex
+func (s *ClientFileManager) DefaultConfig() interface{} { return &ClientFileConfig{} }
+
+// ExtendsKey returns the key of the config to inherit
+func (c *ClientFileConfig) ExtendsKey() string { return c.Extends }
+
+// Inherit returns a new config with the policies of parent merged field by field, the fields it sets take precedence
+func (c *ClientFileConfig) Inherit(parent interface{}) interface{} {
+	p, _ := parent.(*ClientFileConfig)
+	merged := mergeClientConfig(p, c)
+	if merged == c {
+		copied := *c
+		merged = &copied
+	}
+	merged.Extends = ""
+	if _, ok := merged.doc[ExtendsField]; ok {
+		merged.doc = copyDocument(merged.doc)
+		delete(merged.doc, ExtendsField)
+	}
+	return merged
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// durationPattern matches the non-negative duration strings accepted by time.ParseDuration, e.g. "2s", "1m30s", "150ms".
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+var durationRegexp = regexp.MustCompile(durationPattern)
+
+// isMillisecond reports whether the field is a number of milliseconds, such as rpc_timeout_ms,
+// which also accepts duration strings.
+func isMillisecond(name string, s *Schema) bool {
+	return strings.HasSuffix(name, "_ms") && (s.Type == "integer" || s.Type == "number")
+}
+
+// durationSchema accepts either the number of milliseconds described by s, or a duration string.
+func durationSchema(s *Schema) *Schema {
+	number := *s
+	number.Title = s.Type
+	return &Schema{
+		AnyOf: []*Schema{
+			&number,
+			{Title: `duration string, e.g. "150ms"`, Type: "string", Pattern: durationPattern, pattern: durationRegexp},
+		},
+		duration: true,
+	}
+}
+
+// normalizeDuration converts value of a millisecond field into a number of milliseconds if it is a duration string.
+func normalizeDuration(value interface{}, c *schemaCheck) (interface{}, bool) {
+	str, ok := value.(string)
+	if !ok {
+		return value, false
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		c.fail("%s", err.Error())
+		return value, false
+	}
+	if d%time.Millisecond != 0 {
+		c.fail("must be a whole number of milliseconds, got %q", str)
+		return value, false
+	}
+	return float64(d.Milliseconds()), true
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FieldError describes an invalid value in the config file.
+type FieldError struct {
+	Path    string // json path of the value, e.g. $["ClientName/ServiceName"].retry.Echo.type
+	Message string
+	Position
+}
+
+func (e *FieldError) Error() string { return e.Path + ": " + e.Message }
+
+// ValidationErrors is the list of all problems found in a config file.
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// JoinPath appends key to a json path, quoting the key when it is not a plain identifier.
+func JoinPath(path, key string) string {
+	if path == "" {
+		path = "$"
+	}
+	if isIdentifier(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
+
+// isIdentifier reports whether key matches ^[A-Za-z_][A-Za-z0-9_]*$
+func isIdentifier(key string) bool {
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9' {
+			continue
+		}
+		return false
+	}
+	return key != ""
+}
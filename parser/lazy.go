@@ -0,0 +1,107 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bytedance/sonic"
+	"github.com/bytedance/sonic/ast"
+)
+
+// ExtendsField is the json name of the field with which a config extends another key, see Extender.
+const ExtendsField = "extends"
+
+// KeySelector is implemented by managers whose config of a key only depends on some keys of the file.
+type KeySelector interface {
+	// SelectKeys returns the keys read by GetConfig(key), including key itself.
+	SelectKeys(key string) []string
+}
+
+// KeyDecoder is implemented by parsers which can decode the configs of some keys without decoding the whole file.
+type KeyDecoder interface {
+	// DecodeKeys decodes the keys that the configs of keys depend on into config, at least.
+	DecodeKeys(kind ConfigType, data []byte, keys []string, config interface{}) error
+}
+
+var (
+	_ KeySelector = &ClientFileManager{}
+	_ KeySelector = &ServerFileManager{}
+	_ KeyDecoder  = &Parser{}
+)
+
+// SelectKeys returns the key and the wildcard keys matching it.
+func (s *ClientFileManager) SelectKeys(key string) []string { return clientKeyPatterns(key) }
+
+// SelectKeys returns the key and the wildcard key.
+func (s *ServerFileManager) SelectKeys(key string) []string { return serverKeyPatterns(key) }
+
+// DecodeKeys decodes only the keys that the configs of keys depend on, if config is a KeySelector and the data is json:
+// the keys selected by config, the keys they extend, recursively, and VersionKey.
+// The keys are located by sonic without decoding the rest of the file, so the other keys are neither decoded nor validated.
+// Otherwise, or if the data is malformed, it decodes the whole file like Decode.
+func (p *Parser) DecodeKeys(kind ConfigType, data []byte, keys []string, config interface{}) error {
+	s, ok := config.(KeySelector)
+	if !ok || kind != JSON {
+		return p.Decode(kind, data, config)
+	}
+	selected, err := selectKeys(data, keys, s)
+	if err != nil {
+		return p.Decode(kind, data, config) // report the errors of the whole file
+	}
+	// the paths of the errors are the same in data and the selected keys
+	return withPositions(kind, data, p.decode(kind, selected, config))
+}
+
+// selectKeys returns a json document with the keys of data that the configs of keys depend on.
+func selectKeys(data []byte, keys []string, s KeySelector) ([]byte, error) {
+	src := string(data)
+	selected := make(map[string]json.RawMessage)
+	seen := make(map[string]bool)
+	pending := []string{VersionKey}
+	for _, key := range keys {
+		pending = append(pending, s.SelectKeys(key)...)
+	}
+	for len(pending) > 0 {
+		k := pending[0]
+		pending = pending[1:]
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		node, err := ast.NewSearcher(src).GetByPath(k)
+		if errors.Is(err, ast.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		raw, err := node.Raw()
+		if err != nil {
+			return nil, err
+		}
+		selected[k] = json.RawMessage(raw)
+
+		if k == VersionKey || node.Type() != ast.V_OBJECT {
+			continue
+		}
+		if parent, err := node.Get(ExtendsField).StrictString(); err == nil && parent != "" {
+			pending = append(pending, s.SelectKeys(parent)...)
+		}
+	}
+	return sonic.Marshal(selected)
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	data := []byte(`{
+		"version": 1,
+		"*": {"timeout": {"*": {"rpc_timeout_ms": 1000}}},
+		"templates/base": {"retry": {"*": {"type": 0, "failure_policy": {}}}},
+		"checkout/*": {"extends": "templates/base"},
+		"checkout/PaymentService": {"timeout": {"Pay": {"rpc_timeout_ms": "2s"}}},
+		"checkout/OrderService": {"timeout": {"*": {"rpc_timeout_ms": "invalid"}}}
+	}`)
+
+	manager := ClientFileManager{}
+	assert.Nil(t, DefaultConfigParser().(KeyDecoder).DecodeKeys(JSON, data, []string{"checkout/PaymentService"}, &manager))
+	assert.Len(t, manager, 4)
+	assert.Contains(t, manager, "templates/base")
+	assert.Equal(t, 2000, manager["checkout/PaymentService"].Timeout["Pay"].RPCTimeoutMS)
+
+	// the whole file is decoded without a selector, and the invalid key fails it
+	var doc map[string]interface{}
+	assert.Nil(t, DefaultConfigParser().(KeyDecoder).DecodeKeys(JSON, data, []string{"checkout/PaymentService"}, &doc))
+	assert.Len(t, doc, 6)
+	assert.NotNil(t, DefaultConfigParser().(KeyDecoder).DecodeKeys(JSON, data, []string{"checkout/OrderService"}, &ClientFileManager{}))
+
+	// malformed files are decoded as a whole to report the error
+	assert.NotNil(t, DefaultConfigParser().(KeyDecoder).DecodeKeys(JSON, data[:len(data)-2], []string{"checkout/PaymentService"}, &ClientFileManager{}))
+}
+
+// largeClientFile returns a client config file with n keys.
+func largeClientFile(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `"Client%d/Service%d": {
+			"timeout": {"*": {"rpc_timeout_ms": 100, "conn_timeout_ms": 50}, "Echo": {"rpc_timeout_ms": "2s"}},
+			"retry": {"*": {"enable": true, "type": 0, "failure_policy": {"stop_policy": {"max_retry_times": 3, "max_duration_ms": 1000, "cb_policy": {"error_rate": 0.1}}, "backoff_policy": {"backoff_type": "fixed", "cfg_items": {"fix_ms": 50}}}}},
+			"circuitbreaker": {"Echo": {"enable": true, "err_rate": 0.3, "min_sample": 100}}
+		}`, i, i)
+	}
+	buf.WriteString("}")
+	return buf.Bytes()
+}
+
+// BenchmarkUnmarshal is the baseline of BenchmarkDecode, a plain typed decoding without validation.
+func BenchmarkUnmarshal(b *testing.B) {
+	data := bytes.ReplaceAll(largeClientFile(5000), []byte(`"2s"`), []byte("2000"))
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		manager := ClientFileManager{}
+		if err := sonic.Unmarshal(data, &manager); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecode decodes a file which is valid as it is, straight into the config.
+func BenchmarkDecode(b *testing.B) {
+	data := bytes.ReplaceAll(largeClientFile(5000), []byte(`"2s"`), []byte("2000"))
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		manager := ClientFileManager{}
+		if err := DefaultConfigParser().Decode(JSON, data, &manager); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeNormalize decodes a file with duration strings, which is validated and normalized as a document.
+func BenchmarkDecodeNormalize(b *testing.B) {
+	data := largeClientFile(5000)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		manager := ClientFileManager{}
+		if err := DefaultConfigParser().Decode(JSON, data, &manager); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeKeys(b *testing.B) {
+	data := largeClientFile(5000)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		manager := ClientFileManager{}
+		if err := DefaultConfigParser().(KeyDecoder).DecodeKeys(JSON, data, []string{"Client2500/Service2500"}, &manager); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
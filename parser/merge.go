@@ -0,0 +1,254 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/kitex/pkg/klog"
+)
+
+// Wildcard matches any client or service name in a config key.
+const Wildcard = "*"
+
+// clientKeyPatterns returns the keys which match a ClientName/ServiceName key,
+// from the least specific to the most specific: *, ClientName/*, */ServiceName, ClientName/ServiceName.
+func clientKeyPatterns(key string) []string {
+	client, service, found := strings.Cut(key, "/")
+	if !found || key == Wildcard {
+		return []string{Wildcard, key}
+	}
+	return []string{Wildcard, client + "/" + Wildcard, Wildcard + "/" + service, key}
+}
+
+// serverKeyPatterns returns the keys which match a ServiceName key, from the least specific to the most specific.
+func serverKeyPatterns(key string) []string {
+	if key == Wildcard {
+		return []string{key}
+	}
+	return []string{Wildcard, key}
+}
+
+// mergeClientConfig returns a new config with the fields of override merged into base field by field,
+// a field present in override takes precedence over base, even if it is false or 0.
+func mergeClientConfig(base, override *ClientFileConfig) *ClientFileConfig {
+	if base == nil {
+		return override
+	}
+	doc := mergeDocuments(withoutSwitchedRetry(documentOf(base, base.doc), documentOf(override, override.doc)),
+		documentOf(override, override.doc))
+	merged := &ClientFileConfig{doc: doc}
+	if err := decodeDocument(doc, merged); err != nil {
+		// the documents of two valid configs are merged into a valid one
+		klog.Errorf("[local] failed to merge the client configs: %v", err)
+		return override
+	}
+	return merged
+}
+
+// mergeServerConfig returns a new config with the fields of override merged into base field by field,
+// a field present in override takes precedence over base, even if it is 0.
+func mergeServerConfig(base, override *ServerFileConfig) *ServerFileConfig {
+	if base == nil {
+		return override
+	}
+	doc := mergeDocuments(documentOf(base, base.doc), documentOf(override, override.doc))
+	merged := &ServerFileConfig{doc: doc}
+	if err := decodeDocument(doc, merged); err != nil {
+		klog.Errorf("[local] failed to merge the server configs: %v", err)
+		return override
+	}
+	return merged
+}
+
+// mergeDocuments returns a new document with the fields of override merged into base recursively,
+// the fields present in override replace the ones of base, objects are merged key by key.
+// Neither of the documents is modified.
+func mergeDocuments(base, override map[string]interface{}) map[string]interface{} {
+	if len(base) == 0 {
+		return override
+	}
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		b, isObject := merged[k].(map[string]interface{})
+		o, overrides := v.(map[string]interface{})
+		if isObject && overrides {
+			v = mergeDocuments(b, o)
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// withoutSwitchedRetry returns base without the type and the sub-policies of its retry policies
+// which override switches to another type, so that a failure_policy and a backup_policy are not mixed.
+func withoutSwitchedRetry(base, override map[string]interface{}) map[string]interface{} {
+	basePolicies, _ := base["retry"].(map[string]interface{})
+	overridePolicies, _ := override["retry"].(map[string]interface{})
+	var policies map[string]interface{}
+	for method, o := range overridePolicies {
+		b, _ := basePolicies[method].(map[string]interface{})
+		o, _ := o.(map[string]interface{})
+		if b == nil || o == nil || !switchesRetryType(b, o) {
+			continue
+		}
+		if policies == nil {
+			policies = copyDocument(basePolicies)
+		}
+		policy := copyDocument(b)
+		delete(policy, "type")
+		delete(policy, "failure_policy")
+		delete(policy, "backup_policy")
+		policies[method] = policy
+	}
+	if policies == nil {
+		return base
+	}
+	copied := copyDocument(base)
+	copied["retry"] = policies
+	return copied
+}
+
+// switchesRetryType reports whether the retry policy override sets a type or a sub-policy of another type than base.
+func switchesRetryType(base, override map[string]interface{}) bool {
+	_, typed := override["type"]
+	_, failure := override["failure_policy"]
+	_, backup := override["backup_policy"]
+	if !typed && !failure && !backup {
+		return false
+	}
+	baseType, _ := toFloat(base["type"])
+	overrideType, _ := toFloat(override["type"])
+	return baseType != overrideType
+}
+
+func copyDocument(doc map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(doc))
+	for k, v := range doc {
+		copied[k] = v
+	}
+	return copied
+}
+
+// documentOf returns doc, the fields of config present in the file, which is kept by the managers merging keys.
+// For a config without it, such as one built in code, the document of its non-zero fields is returned.
+func documentOf(config interface{}, doc map[string]interface{}) map[string]interface{} {
+	if doc != nil {
+		return doc
+	}
+	data, err := sonic.Marshal(config)
+	if err != nil {
+		return nil
+	}
+	var generic map[string]interface{}
+	if err = sonic.Unmarshal(data, &generic); err != nil {
+		return nil
+	}
+	return withoutZeros(generic)
+}
+
+// withoutZeros removes the null, false, 0 and "" fields of doc recursively.
+func withoutZeros(doc map[string]interface{}) map[string]interface{} {
+	for k, v := range doc {
+		switch v := v.(type) {
+		case nil:
+			delete(doc, k)
+		case bool:
+			if !v {
+				delete(doc, k)
+			}
+		case float64:
+			if v == 0 {
+				delete(doc, k)
+			}
+		case string:
+			if v == "" {
+				delete(doc, k)
+			}
+		case map[string]interface{}:
+			withoutZeros(v)
+		}
+	}
+	return doc
+}
+
+// canonicalDocument returns the document of a value of type t with the fields of its structs named by their json names,
+// which are matched case-insensitively by the decoding, so that the same fields of two documents are merged.
+func canonicalDocument(doc interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return doc
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		canonical := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			canonical[k] = canonicalDocument(v, t.Elem())
+		}
+		return canonical
+	case reflect.Struct:
+		fields := structFields(t)
+		canonical := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			field := findField(fields, k)
+			if field == nil {
+				canonical[k] = v
+				continue
+			}
+			canonical[field.name] = canonicalDocument(v, t.FieldByIndex(field.index).Type)
+		}
+		return canonical
+	}
+	return doc
+}
+
+var fieldsCache sync.Map // reflect.Type -> []*structField
+
+// structFields returns the cached fields of a struct found by their json names, without their decoders.
+func structFields(t reflect.Type) []*structField {
+	if fields, ok := fieldsCache.Load(t); ok {
+		return fields.([]*structField)
+	}
+	var fields []*structField
+	collectFields(t, nil, &fields)
+	fieldsCache.Store(t, fields)
+	return fields
+}
+
+// findField returns the field named name, matched exactly or else case-insensitively like structDecoder.
+func findField(fields []*structField, name string) *structField {
+	var folded *structField
+	for _, f := range fields {
+		if f.name == name {
+			return f
+		}
+		if folded == nil && strings.EqualFold(f.name, name) {
+			folded = f
+		}
+	}
+	return folded
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientWildcard(t *testing.T) {
+	manager := ClientFileManager{}
+	assert.Nil(t, DefaultConfigParser().Decode(JSON, []byte(`{
+		"*": {"timeout": {"*": {"rpc_timeout_ms": 1000}, "Echo": {"rpc_timeout_ms": 1000}}},
+		"checkout/*": {"timeout": {"Echo": {"rpc_timeout_ms": 2000}}},
+		"*/PaymentService": {"timeout": {"Echo": {"rpc_timeout_ms": 3000}}},
+		"checkout/PaymentService": {"retry": {"Pay": {"type": 0, "failure_policy": {}}}}
+	}`), &manager))
+
+	config := manager.GetConfig("checkout/PaymentService").(*ClientFileConfig)
+	assert.Equal(t, 1000, config.Timeout["*"].RPCTimeoutMS)
+	assert.Equal(t, 3000, config.Timeout["Echo"].RPCTimeoutMS)
+	assert.Contains(t, config.Retry, "Pay")
+
+	config = manager.GetConfig("checkout/OrderService").(*ClientFileConfig)
+	assert.Equal(t, 2000, config.Timeout["Echo"].RPCTimeoutMS)
+	assert.Empty(t, config.Retry)
+
+	config = manager.GetConfig("cart/OrderService").(*ClientFileConfig)
+	assert.Equal(t, 1000, config.Timeout["Echo"].RPCTimeoutMS)
+
+	// the configs in the file are not modified by merging
+	assert.Len(t, manager["checkout/PaymentService"].Timeout, 0)
+	assert.Nil(t, (&ClientFileManager{}).GetConfig("checkout/PaymentService"))
+}
+
+func TestClientWildcardFields(t *testing.T) {
+	manager := ClientFileManager{}
+	assert.Nil(t, DefaultConfigParser().Decode(JSON, []byte(`{
+		"*": {
+			"timeout": {"*": {"rpc_timeout_ms": 1000}},
+			"retry": {"*": {"enable": true, "type": 0, "failure_policy": {"stop_policy": {"max_retry_times": 2}}}},
+			"circuitbreaker": {"*": {"enable": true, "err_rate": 0.5}}
+		},
+		"c/*": {
+			"timeout": {"*": {"conn_timeout_ms": 50}},
+			"retry": {"*": {"failure_policy": {"retry_same_node": true}}},
+			"circuitbreaker": {"*": {"min_sample": 100}}
+		},
+		"*/backup": {"retry": {"*": {"type": 1, "backup_policy": {"retry_delay_ms": 10}}}}
+	}`), &manager))
+
+	config := manager.GetConfig("c/s").(*ClientFileConfig)
+	assert.Equal(t, 1000, config.Timeout["*"].RPCTimeoutMS)
+	assert.Equal(t, 50, config.Timeout["*"].ConnTimeoutMS)
+	assert.True(t, config.Retry["*"].Enable)
+	assert.Equal(t, 2, config.Retry["*"].FailurePolicy.StopPolicy.MaxRetryTimes)
+	assert.True(t, config.Retry["*"].FailurePolicy.RetrySameNode)
+	assert.True(t, config.Circuitbreaker["*"].Enable)
+	assert.Equal(t, 0.5, config.Circuitbreaker["*"].ErrRate)
+	assert.Equal(t, int64(100), config.Circuitbreaker["*"].MinSample)
+
+	// the type of a more specific key setting a policy is used
+	config = manager.GetConfig("c/backup").(*ClientFileConfig)
+	assert.Equal(t, retry.BackupType, config.Retry["*"].Type)
+	assert.Nil(t, config.Retry["*"].FailurePolicy)
+	assert.Equal(t, uint32(10), config.Retry["*"].BackupPolicy.RetryDelayMS)
+	assert.True(t, config.Retry["*"].Enable)
+
+	// the configs in the file are not modified by merging
+	assert.Zero(t, manager["*"].Timeout["*"].ConnTimeoutMS)
+	assert.False(t, manager["*"].Retry["*"].FailurePolicy.RetrySameNode)
+}
+
+func TestClientWildcardExplicitZero(t *testing.T) {
+	data := []byte(`{
+		"*": {
+			"retry": {"Pay": {"enable": true, "type": 0, "failure_policy": {"stop_policy": {"max_retry_times": 2}}}},
+			"circuitbreaker": {"Pay": {"enable": true, "err_rate": 0.5}}
+		},
+		"checkout/Payment": {
+			"retry": {"Pay": {"enable": false, "failure_policy": {"stop_policy": {"max_retry_times": 0}}}},
+			"circuitbreaker": {"Pay": {"enable": false}}
+		}
+	}`)
+	// decoded straight and by the validating decoding, which normalizes the duration string
+	normalized := bytes.Replace(data, []byte(`"*": {`), []byte(`"*": {"timeout": {"*": {"rpc_timeout_ms": "1s"}},`), 1)
+	for _, data := range [][]byte{data, normalized} {
+		manager := ClientFileManager{}
+		assert.Nil(t, DefaultConfigParser().Decode(JSON, data, &manager))
+
+		config := manager.GetConfig("checkout/Payment").(*ClientFileConfig)
+		assert.False(t, config.Retry["Pay"].Enable)
+		assert.Zero(t, config.Retry["Pay"].FailurePolicy.StopPolicy.MaxRetryTimes)
+		assert.False(t, config.Circuitbreaker["Pay"].Enable)
+		assert.Equal(t, 0.5, config.Circuitbreaker["Pay"].ErrRate)
+
+		config = manager.GetConfig("checkout/Order").(*ClientFileConfig)
+		assert.True(t, config.Retry["Pay"].Enable)
+		assert.Equal(t, 2, config.Retry["Pay"].FailurePolicy.StopPolicy.MaxRetryTimes)
+	}
+
+	// the configs built in code are merged by their non-zero fields
+	config := mergeClientConfig(&ClientFileConfig{
+		Retry: map[string]*retry.Policy{"Pay": {Enable: true, FailurePolicy: &retry.FailurePolicy{RetrySameNode: true}}},
+	}, &ClientFileConfig{
+		Retry: map[string]*retry.Policy{"Pay": {FailurePolicy: &retry.FailurePolicy{StopPolicy: retry.StopPolicy{MaxRetryTimes: 3}}}},
+	})
+	assert.True(t, config.Retry["Pay"].Enable)
+	assert.True(t, config.Retry["Pay"].FailurePolicy.RetrySameNode)
+	assert.Equal(t, 3, config.Retry["Pay"].FailurePolicy.StopPolicy.MaxRetryTimes)
+}
+
+func TestServerWildcard(t *testing.T) {
+	manager := ServerFileManager{}
+	assert.Nil(t, DefaultConfigParser().Decode(JSON, []byte(`{
+		"*": {"limit": {"connection_limit": 300, "qps_limit": 200}},
+		"PaymentService": {"limit": {"qps_limit": 100}}
+	}`), &manager))
+
+	config := manager.GetConfig("PaymentService").(*ServerFileConfig)
+	assert.Equal(t, int64(300), config.Limit.ConnectionLimit)
+	assert.Equal(t, int64(100), config.Limit.QPSLimit)
+
+	config = manager.GetConfig("OrderService").(*ServerFileConfig)
+	assert.Equal(t, int64(200), config.Limit.QPSLimit)
+
+	// an explicit 0 removes the limit of the wildcard key
+	assert.Nil(t, DefaultConfigParser().Decode(JSON, []byte(`{
+		"*": {"limit": {"connection_limit": 300, "qps_limit": 200}},
+		"PaymentService": {"limit": {"qps_limit": 0}}
+	}`), &manager))
+	config = manager.GetConfig("PaymentService").(*ServerFileConfig)
+	assert.Equal(t, int64(300), config.Limit.ConnectionLimit)
+	assert.Zero(t, config.Limit.QPSLimit)
+}
+
+func TestInheritExplicitZero(t *testing.T) {
+	manager := ServerFileManager{}
+	assert.Nil(t, DefaultConfigParser().Decode(JSON, []byte(`{
+		"templates/limited": {"limit": {"connection_limit": 300, "qps_limit": 200}},
+		"PaymentService": {"extends": "templates/limited", "limit": {"qps_limit": 0}}
+	}`), &manager))
+
+	config := manager["PaymentService"].Inherit(manager["templates/limited"]).(*ServerFileConfig)
+	assert.Equal(t, int64(300), config.Limit.ConnectionLimit)
+	assert.Zero(t, config.Limit.QPSLimit)
+	assert.Empty(t, config.Extends)
+	assert.Equal(t, "templates/limited", manager["PaymentService"].Extends)
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RegexPrefix marks a method key as a regular expression, e.g. "re:^List.*$".
+const RegexPrefix = "re:"
+
+// methodPattern is a method key which matches several methods.
+type methodPattern struct {
+	key   string
+	regex *regexp.Regexp // nil for glob
+}
+
+// IsMethodPattern reports whether the method key is a glob, such as "Get*", or a regular expression, such as "re:^List.*$".
+// The plain "*" is not a pattern, it is the default policy handled by kitex.
+func IsMethodPattern(key string) bool {
+	return key != Wildcard && (strings.HasPrefix(key, RegexPrefix) || strings.ContainsAny(key, "*?["))
+}
+
+// compileMethodPattern compiles a method key for which IsMethodPattern is true.
+func compileMethodPattern(key string) (*methodPattern, error) {
+	if strings.HasPrefix(key, RegexPrefix) {
+		re, err := regexp.Compile(strings.TrimPrefix(key, RegexPrefix))
+		if err != nil {
+			return nil, err
+		}
+		return &methodPattern{key: key, regex: re}, nil
+	}
+	if _, err := path.Match(key, ""); err != nil {
+		return nil, err
+	}
+	return &methodPattern{key: key}, nil
+}
+
+func (p *methodPattern) match(method string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(method)
+	}
+	ok, _ := path.Match(p.key, method)
+	return ok
+}
+
+// literalLen is the number of characters which are not wildcards, the more the pattern is more specific.
+func (p *methodPattern) literalLen() int {
+	return len(p.key) - strings.Count(p.key, "*") - strings.Count(p.key, "?")
+}
+
+// sortMethodPatterns orders the patterns deterministically by precedence:
+// globs before regular expressions, more specific globs first, then in lexical order.
+func sortMethodPatterns(patterns []*methodPattern) {
+	sort.Slice(patterns, func(i, j int) bool {
+		pi, pj := patterns[i], patterns[j]
+		if (pi.regex == nil) != (pj.regex == nil) {
+			return pi.regex == nil
+		}
+		if pi.regex == nil && pi.literalLen() != pj.literalLen() {
+			return pi.literalLen() > pj.literalLen()
+		}
+		return pi.key < pj.key
+	})
+}
+
+// ExpandMethods returns the policies keyed by method names only, which can be used by the kitex containers.
+// Method names and "*" are kept, and the policy of each pattern key is copied to the methods it matches.
+// A method name always takes precedence over the patterns, and the first matching pattern by precedence wins:
+// globs before regular expressions, more specific globs first, then in lexical order. Invalid patterns are dropped.
+func ExpandMethods[T any](policies map[string]*T, methods []string) map[string]*T {
+	var patterns []*methodPattern
+	for key := range policies {
+		if !IsMethodPattern(key) {
+			continue
+		}
+		if p, err := compileMethodPattern(key); err == nil {
+			patterns = append(patterns, p)
+		}
+	}
+	if len(patterns) == 0 {
+		return policies
+	}
+	sortMethodPatterns(patterns)
+
+	expanded := make(map[string]*T, len(policies)+len(methods))
+	for key, policy := range policies {
+		if !IsMethodPattern(key) {
+			expanded[key] = policy
+		}
+	}
+	for _, method := range methods {
+		if _, ok := expanded[method]; ok {
+			continue
+		}
+		for _, p := range patterns {
+			if p.match(method) {
+				expanded[method] = policies[p.key]
+				break
+			}
+		}
+	}
+	return expanded
+}
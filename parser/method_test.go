@@ -0,0 +1,60 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/rpctimeout"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandMethods(t *testing.T) {
+	policies := map[string]*rpctimeout.RPCTimeout{
+		"*":              {RPCTimeoutMS: 1},
+		"GetUser":        {RPCTimeoutMS: 2},
+		"Get*":           {RPCTimeoutMS: 3},
+		"GetOrder*":      {RPCTimeoutMS: 4},
+		"re:^(List|Get)": {RPCTimeoutMS: 5},
+		"re:(":           {RPCTimeoutMS: 6},
+	}
+	methods := []string{"GetUser", "GetItem", "GetOrderList", "ListUsers", "Pay"}
+
+	expanded := ExpandMethods(policies, methods)
+	assert.Equal(t, 1, expanded["*"].RPCTimeoutMS)
+	assert.Equal(t, 2, expanded["GetUser"].RPCTimeoutMS)      // exact name wins
+	assert.Equal(t, 3, expanded["GetItem"].RPCTimeoutMS)      // glob before regex
+	assert.Equal(t, 4, expanded["GetOrderList"].RPCTimeoutMS) // more specific glob
+	assert.Equal(t, 5, expanded["ListUsers"].RPCTimeoutMS)
+	assert.NotContains(t, expanded, "Pay")
+	assert.NotContains(t, expanded, "Get*")
+	assert.NotContains(t, expanded, "re:(")
+
+	// without patterns the policies are returned as is
+	plain := map[string]*rpctimeout.RPCTimeout{"Echo": {}}
+	assert.Equal(t, plain, ExpandMethods(plain, methods))
+}
+
+func TestValidateMethodPattern(t *testing.T) {
+	config := &ClientFileConfig{Timeout: map[string]*rpctimeout.RPCTimeout{
+		"Get*": {},
+		"re:(": {},
+		"[":    {},
+	}}
+	errs := config.Validate().(ValidationErrors)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, `$.timeout["["]`, errs[0].Path)
+	assert.Equal(t, `$.timeout["re:("]`, errs[1].Path)
+}
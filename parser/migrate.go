@@ -0,0 +1,134 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+)
+
+const (
+	// VersionKey is the optional top-level key which declares the format version of the file.
+	VersionKey = "version"
+	// InitialVersion is the version of files without VersionKey.
+	InitialVersion = 1
+)
+
+// Migrator upgrades a document of version From to version From+1.
+// The document is the generic form of the file without VersionKey, e.g. map[string]interface{}.
+type Migrator struct {
+	From int
+	// Deprecated is logged as a warning when a document of version From is loaded, optional.
+	Deprecated string
+	Migrate    func(doc map[string]interface{}) (map[string]interface{}, error)
+}
+
+var (
+	migratorsLock sync.RWMutex
+	migrators     = map[reflect.Type]map[int]Migrator{} // config type -> from version -> migrator
+)
+
+// RegisterMigrator registers m for the config type of manager, e.g. ClientFileManager{}.
+// Registering a migrator of version n makes n+1 the current version of the type, if it is the highest one.
+func RegisterMigrator(manager interface{}, m Migrator) {
+	if m.From < InitialVersion || m.Migrate == nil {
+		panic(fmt.Sprintf("invalid migrator from version %d", m.From))
+	}
+	t := indirect(reflect.TypeOf(manager))
+
+	migratorsLock.Lock()
+	defer migratorsLock.Unlock()
+	if migrators[t] == nil {
+		migrators[t] = map[int]Migrator{}
+	}
+	migrators[t][m.From] = m
+}
+
+// CurrentVersion returns the version that the documents of the config type of manager are migrated to.
+func CurrentVersion(manager interface{}) int {
+	migratorsLock.RLock()
+	defer migratorsLock.RUnlock()
+	return currentVersion(indirect(reflect.TypeOf(manager)))
+}
+
+func currentVersion(t reflect.Type) int {
+	version := InitialVersion
+	for from := range migrators[t] {
+		if from+1 > version {
+			version = from + 1
+		}
+	}
+	return version
+}
+
+// migrate strips VersionKey from doc and upgrades it to the current version of t step by step,
+// it reports whether the returned document differs from doc.
+// Generic documents, which are decoded into interface{} or map[string]interface{}, are returned as is.
+// The warnings of deprecated versions are only logged if warn is true.
+func migrate(t reflect.Type, doc interface{}, warn bool) (interface{}, bool, error) {
+	t = indirect(t)
+	m, ok := doc.(map[string]interface{})
+	if !ok || isGeneric(t) {
+		return doc, false, nil
+	}
+	changed := false
+
+	version := InitialVersion
+	if raw, ok := m[VersionKey]; ok {
+		f, isNumber := toFloat(raw)
+		if !isNumber || f != float64(int(f)) || int(f) < InitialVersion {
+			return nil, false, ValidationErrors{{Path: JoinPath("", VersionKey), Message: fmt.Sprintf("must be an integer not less than %d, got %s", InitialVersion, describe(raw))}}
+		}
+		version = int(f)
+		delete(m, VersionKey)
+		changed = true
+	}
+
+	migratorsLock.RLock()
+	defer migratorsLock.RUnlock()
+	current := currentVersion(t)
+	if version > current {
+		return nil, false, fmt.Errorf("unsupported config version %d, the latest one is %d", version, current)
+	}
+	for ; version < current; version++ {
+		migrator, ok := migrators[t][version]
+		if !ok {
+			return nil, false, fmt.Errorf("no migrator from config version %d", version)
+		}
+		if warn && migrator.Deprecated != "" {
+			klog.Warnf("[local] config version %d is deprecated: %s", version, migrator.Deprecated)
+		}
+		var err error
+		changed = true
+		if m, err = migrator.Migrate(m); err != nil {
+			return nil, false, fmt.Errorf("migrate config from version %d failed: %w", version, err)
+		}
+	}
+	return m, changed, nil
+}
+
+func isGeneric(t reflect.Type) bool {
+	return t == nil || t.Kind() == reflect.Interface || (t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Interface)
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
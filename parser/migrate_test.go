@@ -0,0 +1,71 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// migrateManager has its own migrators, so that the ones of ClientFileManager are not changed by the test.
+type migrateManager map[string]*ClientFileConfig
+
+func TestMigrate(t *testing.T) {
+	// version 1 named the category "rpc_timeout", version 2 renamed it to "timeout"
+	RegisterMigrator(migrateManager{}, Migrator{
+		From:       1,
+		Deprecated: `rename "rpc_timeout" to "timeout"`,
+		Migrate: func(doc map[string]interface{}) (map[string]interface{}, error) {
+			for _, config := range doc {
+				if c, ok := config.(map[string]interface{}); ok && c["rpc_timeout"] != nil {
+					c["timeout"] = c["rpc_timeout"]
+					delete(c, "rpc_timeout")
+				}
+			}
+			return doc, nil
+		},
+	})
+	assert.Equal(t, 2, CurrentVersion(&migrateManager{}))
+	assert.Equal(t, InitialVersion, CurrentVersion(ClientFileManager{}))
+
+	for _, data := range []string{
+		`{"Client/Service": {"rpc_timeout": {"*": {"rpc_timeout_ms": 100}}}}`,
+		`{"version": 1, "Client/Service": {"rpc_timeout": {"*": {"rpc_timeout_ms": 100}}}}`,
+		`{"version": 2, "Client/Service": {"timeout": {"*": {"rpc_timeout_ms": 100}}}}`,
+	} {
+		manager := migrateManager{}
+		assert.Nil(t, StrictParser(DefaultConfigParser()).Decode(JSON, []byte(data), &manager), data)
+		assert.Len(t, manager, 1)
+		assert.Equal(t, 100, manager["Client/Service"].Timeout["*"].RPCTimeoutMS)
+	}
+
+	err := DefaultConfigParser().Decode(JSON, []byte(`{"version": 3}`), &migrateManager{})
+	assert.Equal(t, "unsupported config version 3, the latest one is 2", err.Error())
+	err = DefaultConfigParser().Decode(JSON, []byte(`{"version": "1"}`), &migrateManager{})
+	assert.Equal(t, `$.version: must be an integer not less than 1, got string "1"`, err.Error())
+}
+
+func TestVersionStripped(t *testing.T) {
+	manager := ServerFileManager{}
+	assert.Nil(t, DefaultConfigParser().Decode(YAML, []byte("version: 1\nServiceName:\n  limit:\n    qps_limit: 10\n"), &manager))
+	assert.Len(t, manager, 1)
+	assert.Equal(t, int64(10), manager["ServiceName"].Limit.QPSLimit)
+
+	// generic documents keep the version, e.g. the layers of a file
+	var doc map[string]interface{}
+	assert.Nil(t, DefaultConfigParser().Decode(JSON, []byte(`{"version": 1}`), &doc))
+	assert.Contains(t, doc, VersionKey)
+}
@@ -16,8 +16,10 @@ package parser
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/bytedance/sonic"
+	"github.com/cloudwego/kitex/pkg/klog"
 	"sigs.k8s.io/yaml"
 )
 
@@ -43,10 +45,134 @@ type ConfigManager interface {
 	GetConfig(key string) interface{}
 }
 
+// Extender is implemented by configs which can inherit the config of another key in the same file.
+type Extender interface {
+	// ExtendsKey returns the key of the parent config, empty if there is none.
+	ExtendsKey() string
+	// Inherit returns a new config with the parent merged, without modifying either of them.
+	Inherit(parent interface{}) interface{}
+}
+
+// WildcardManager is implemented by managers whose GetConfig merges the configs of the wildcard keys,
+// so that the extends chain is resolved on the configs of the keys as written in the file.
+type WildcardManager interface {
+	// ExactConfig returns the config of the key as written in the file, nil if it is absent.
+	ExactConfig(key string) interface{}
+	// WildcardConfig returns the merged configs of the wildcard keys matching the key, nil if there is none.
+	WildcardConfig(key string) interface{}
+	// WildcardKeys returns the wildcard keys matching the key, from the least specific to the most specific.
+	WildcardKeys(key string) []string
+}
+
+// ConfigDefaulter is implemented by managers which provide the config of a key absent from the file,
+// so that removing the key restores the defaults of kitex.
+type ConfigDefaulter interface {
+	DefaultConfig() interface{}
+}
+
 var _ ConfigParser = &Parser{}
 
 // Decode decodes the data to struct in specified format.
+// The data is validated against the schema generated from the type of config first,
+// invalid values fail the decoding, and unknown fields are reported as warnings.
+// Millisecond fields, such as rpc_timeout_ms, accept duration strings like "2s" as well as integers.
+// Documents of an older version are migrated by the registered migrators before the validation, see RegisterMigrator.
+// Multi-document yaml is merged into one document, a key repeated across the documents fails the decoding.
+// The syntax errors are returned as DecodeError, and the ValidationErrors are located in data if possible.
+// The document is parsed once: json which is valid as it is is decoded straight into config,
+// and the others are decoded from the generic document which has been validated and normalized.
 func (p *Parser) Decode(kind ConfigType, data []byte, config interface{}) error {
+	return withPositions(kind, data, p.decode(kind, data, config))
+}
+
+func (p *Parser) decode(kind ConfigType, data []byte, config interface{}) error {
+	if kind == JSON && decodeTyped(data, config) {
+		if k, ok := config.(documentKeeper); ok && k.keepsDocuments() {
+			// only the files merging keys are parsed again for the fields present
+			var doc map[string]interface{}
+			if err := p.unmarshal(kind, data, &doc); err != nil {
+				return syntaxError(kind, data, 1, err)
+			}
+			k.keepDocuments(doc)
+		}
+		return nil
+	}
+
+	var doc interface{}
+	if kind == YAML {
+		var err error
+		if doc, err = unmarshalYAMLDocuments(data); err != nil {
+			return err
+		}
+	} else if err := p.unmarshal(kind, data, &doc); err != nil {
+		return syntaxError(kind, data, 1, err)
+	}
+
+	doc, _, err := migrate(reflect.TypeOf(config), doc, true)
+	if err != nil {
+		return err
+	}
+
+	doc, _, errs, unknown := schemaOf(reflect.TypeOf(config)).validateAndNormalize(doc)
+	for _, path := range unknown {
+		klog.Warnf("[local] unknown field %s in config, ignored", path)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// the document is decoded into config directly, without parsing data again
+	if err := decodeDocument(doc, config); err != nil {
+		return err
+	}
+	if k, ok := config.(documentKeeper); ok && k.keepsDocuments() {
+		m, _ := doc.(map[string]interface{})
+		k.keepDocuments(m)
+	}
+	return nil
+}
+
+// documentKeeper is implemented by the managers whose configs are merged with the configs of other keys.
+// The configs keep the fields present in the file, so that a false or 0 set explicitly overrides when merged.
+type documentKeeper interface {
+	keepsDocuments() bool
+	keepDocuments(doc map[string]interface{})
+}
+
+// strictJSON decodes the json files which are valid as they are, see decodeTyped.
+var strictJSON = sonic.Config{DisallowUnknownFields: true}.Froze()
+
+// decodeTyped decodes json data straight into config if it is valid as it is: it needs no migration,
+// has no unknown fields or duration strings, and its values match the types and enums of config.
+// It reports false to fall back to the validating decoding, which reports the problems of data.
+func decodeTyped(data []byte, config interface{}) bool {
+	dst := reflect.ValueOf(config)
+	if dst.Kind() != reflect.Ptr || dst.IsNil() || isGeneric(indirect(dst.Type())) {
+		return false
+	}
+	migratorsLock.RLock()
+	current := currentVersion(indirect(dst.Type()))
+	migratorsLock.RUnlock()
+	if current != InitialVersion {
+		return false
+	}
+
+	// decoded into a new value, so that config is not touched if it falls back
+	v := reflect.New(dst.Type().Elem())
+	if strictJSON.Unmarshal(data, v.Interface()) != nil || !validEnums(v) {
+		return false
+	}
+	if dst = dst.Elem(); dst.Kind() == reflect.Map && !dst.IsNil() {
+		for iter := v.Elem().MapRange(); iter.Next(); {
+			dst.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return true
+	}
+	dst.Set(v.Elem())
+	return true
+}
+
+func (p *Parser) unmarshal(kind ConfigType, data []byte, config interface{}) error {
 	switch kind {
 	case JSON:
 		return sonic.Unmarshal(data, config)
@@ -57,8 +183,11 @@ func (p *Parser) Decode(kind ConfigType, data []byte, config interface{}) error
 	}
 }
 
+var defaultParser = &Parser{}
+
+// DefaultConfigParser returns the shared default parser, which is stateless.
 func DefaultConfigParser() ConfigParser {
-	return &Parser{}
+	return defaultParser
 }
 
 func DefaultConfigParam() *ConfigParam {
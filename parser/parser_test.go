@@ -0,0 +1,122 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/kitex/pkg/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeDuration(t *testing.T) {
+	for kind, data := range map[ConfigType]string{
+		JSON: `{"c/s": {
+			"timeout": {"*": {"rpc_timeout_ms": "2s", "conn_timeout_ms": 50}},
+			"retry": {"*": {"type": 0, "failure_policy": {
+				"stop_policy": {"max_duration_ms": "1m"},
+				"backoff_policy": {"backoff_type": "fixed", "cfg_items": {"fix_ms": "150ms"}}
+			}}}
+		}}`,
+		YAML: `
+c/s:
+  timeout:
+    "*": {rpc_timeout_ms: 2s, conn_timeout_ms: 50}
+  retry:
+    "*":
+      type: 0
+      failure_policy:
+        stop_policy: {max_duration_ms: 1m}
+        backoff_policy: {backoff_type: fixed, cfg_items: {fix_ms: 150ms}}
+`,
+	} {
+		manager := ClientFileManager{}
+		assert.Nil(t, DefaultConfigParser().Decode(kind, []byte(data), &manager), kind)
+
+		config := manager["c/s"]
+		assert.Equal(t, 2000, config.Timeout["*"].RPCTimeoutMS, kind)
+		assert.Equal(t, 50, config.Timeout["*"].ConnTimeoutMS, kind)
+		fp := config.Retry["*"].FailurePolicy
+		assert.Equal(t, uint32(60000), fp.StopPolicy.MaxDurationMS, kind)
+		assert.Equal(t, float64(150), fp.BackOffPolicy.CfgItems[retry.FixMSBackOffCfgKey], kind)
+	}
+
+	err := DefaultConfigParser().Decode(JSON, []byte(`{"c/s": {"timeout": {"*": {"rpc_timeout_ms": "2 seconds"}}}}`), &ClientFileManager{})
+	assert.Contains(t, err.Error(), `$["c/s"].timeout["*"].rpc_timeout_ms: expected integer or duration string`)
+	err = DefaultConfigParser().Decode(JSON, []byte(`{"c/s": {"timeout": {"*": {"rpc_timeout_ms": "1500us"}}}}`), &ClientFileManager{})
+	assert.Contains(t, err.Error(), "whole number of milliseconds")
+}
+
+func TestDecodeDocument(t *testing.T) {
+	type Inner struct {
+		Name string `json:"name"`
+	}
+	type config struct {
+		Inner
+		Count   int               `json:"count"`
+		Ratio   float64           `json:"ratio"`
+		Enabled bool              `json:"enabled"`
+		Tags    []string          `json:"tags"`
+		Items   map[string]*Inner `json:"items"`
+		Ptr     *Inner            `json:"ptr"`
+		Any     interface{}       `json:"any"`
+		Raw     json.RawMessage   `json:"raw"`
+		Skipped string            `json:"-"`
+	}
+	data := `{"name": "n", "COUNT": 3, "ratio": 0.5, "enabled": true, "tags": ["a", "b"],
+		"items": {"x": {"name": "x"}, "y": null}, "ptr": {"name": "p"}, "any": {"k": [1]}, "raw": {"r":1}, "Skipped": "s"}`
+
+	var doc interface{}
+	assert.Nil(t, sonic.UnmarshalString(data, &doc))
+	var got, want config
+	assert.Nil(t, decodeDocument(doc, &got))
+	assert.Nil(t, json.Unmarshal([]byte(data), &want))
+	assert.Equal(t, want, got)
+
+	// null keeps the values which are not nullable
+	got = config{Count: 1, Tags: []string{"a"}}
+	assert.Nil(t, decodeDocument(map[string]interface{}{"count": nil, "tags": nil}, &got))
+	assert.Equal(t, 1, got.Count)
+	assert.Nil(t, got.Tags)
+
+	assert.NotNil(t, decodeDocument(map[string]interface{}{"count": 1.5}, &got))
+	assert.NotNil(t, decodeDocument(doc, got))
+}
+
+func TestDecodeTyped(t *testing.T) {
+	// valid as it is
+	data := []byte(`{"c/s": {"retry": {"*": {"enable": true, "type": 1, "backup_policy": {"retry_delay_ms": 10}}}}}`)
+	manager := ClientFileManager{}
+	assert.True(t, decodeTyped(data, &manager))
+	assert.Equal(t, uint32(10), manager["c/s"].Retry["*"].BackupPolicy.RetryDelayMS)
+
+	// the others fall back to the validating decoding, without touching the config
+	for _, data := range []string{
+		`{"c/s": {"retry": {"*": {"type": 2}}}}`,
+		`{"c/s": {"retry": {"*": {"type": 0, "failure_policy": {"backoff_policy": {"cfg_items": {"unknown_ms": 1}}}}}}}`,
+		`{"c/s": {"timeout": {"*": {"rpc_timeout_ms": "2s"}}}}`,
+		`{"c/s": {"circuit_breaker": {}}}`,
+		`{"version": 1, "c/s": {}}`,
+	} {
+		manager := ClientFileManager{}
+		assert.False(t, decodeTyped([]byte(data), &manager), data)
+		assert.Empty(t, manager, data)
+	}
+
+	err := DefaultConfigParser().Decode(JSON, []byte(`{"c/s": {"retry": {"*": {"type": 2}}}}`), &ClientFileManager{})
+	assert.ErrorContains(t, err, `$["c/s"].retry["*"].type: expected one of 0, 1, got number 2`)
+}
@@ -0,0 +1,418 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Position locates a problem in a config file.
+type Position struct {
+	File    string // path of the file, set by the monitor
+	Line    int    // from 1, 0 if unknown
+	Column  int    // in bytes from 1, 0 if unknown
+	Snippet string // text of the line
+}
+
+// String returns the position as file:line:column, omitting the unknown parts.
+func (p Position) String() string {
+	s := p.File
+	if p.Line > 0 {
+		if s != "" {
+			s += ":"
+		}
+		s += strconv.Itoa(p.Line)
+		if p.Column > 0 {
+			s += ":" + strconv.Itoa(p.Column)
+		}
+	}
+	return s
+}
+
+// DecodeError is the error of decoding a config file, with the position of the problem if it is known.
+// It is returned by the monitor when a reload fails to decode, and wraps ValidationErrors if the values are invalid,
+// in which case the position and the path are the ones of the first invalid value.
+type DecodeError struct {
+	Position
+	Path string // json path of the problem, e.g. $.ServiceName.limit.qps_limit, empty for syntax errors
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	msg := e.Err.Error()
+	var errs ValidationErrors
+	if e.Path != "" && !errors.As(e.Err, &errs) {
+		msg = e.Path + ": " + msg
+	}
+	if pos := e.Position.String(); pos != "" {
+		return pos + ": " + msg
+	}
+	return msg
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// Locate returns the position of the key of the value at the json path in data, e.g. $.ServiceName.limit.qps_limit,
+// the documents of multi-document yaml are searched in order.
+func Locate(kind ConfigType, data []byte, path string) (Position, bool) {
+	segments, ok := splitPath(path)
+	if !ok {
+		return Position{}, false
+	}
+	switch kind {
+	case JSON:
+		s := &jsonScanner{data: data}
+		if offset, ok := s.locate(segments); ok {
+			return positionAt(data, offset), true
+		}
+	case YAML:
+		dec := yamlv3.NewDecoder(bytes.NewReader(data))
+		for {
+			var node yamlv3.Node
+			if err := dec.Decode(&node); err != nil {
+				break
+			}
+			if line, column, ok := locateYAML(&node, segments); ok {
+				return Position{Line: line, Column: column, Snippet: lineAt(data, line)}, true
+			}
+		}
+	}
+	return Position{}, false
+}
+
+// locate fills the positions of the errors in data, leaving the ones not found unknown.
+func (e ValidationErrors) locate(kind ConfigType, data []byte) {
+	for _, err := range e {
+		if pos, ok := Locate(kind, data, err.Path); ok {
+			err.Position = pos
+		}
+	}
+}
+
+// withPositions locates the errors of decoding data.
+func withPositions(kind ConfigType, data []byte, err error) error {
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		errs.locate(kind, data)
+	}
+	return err
+}
+
+var yamlLine = regexp.MustCompile(`yaml: line (\d+):`)
+
+// syntaxError returns the DecodeError of err, the syntax error of the yaml document starting at firstLine of data,
+// or of the whole json data.
+func syntaxError(kind ConfigType, data []byte, firstLine int, err error) error {
+	var pos Position
+	switch kind {
+	case JSON:
+		// the errors of sonic differ between platforms, so the offset is found by encoding/json
+		var v interface{}
+		var se *json.SyntaxError
+		if errors.As(json.Unmarshal(data, &v), &se) && se.Offset > 0 {
+			pos = positionAt(data, int(se.Offset)-1) // the offset is after the invalid byte
+		}
+	case YAML:
+		if m := yamlLine.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			line += firstLine - 1
+			pos = Position{Line: line, Snippet: lineAt(data, line)}
+		}
+	}
+	return &DecodeError{Position: pos, Err: err}
+}
+
+// positionAt returns the position of the byte at offset.
+func positionAt(data []byte, offset int) Position {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return Position{Line: line, Column: column, Snippet: lineAt(data, line)}
+}
+
+// lineAt returns the text of the line, from 1.
+func lineAt(data []byte, line int) string {
+	for i := 1; i < line; i++ {
+		next := bytes.IndexByte(data, '\n')
+		if next < 0 {
+			return ""
+		}
+		data = data[next+1:]
+	}
+	if end := bytes.IndexByte(data, '\n'); end >= 0 {
+		data = data[:end]
+	}
+	return string(bytes.TrimRight(data, "\r"))
+}
+
+// splitPath splits a json path built by JoinPath into keys and array indexes.
+func splitPath(path string) ([]interface{}, bool) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, false
+	}
+	var segments []interface{}
+	for rest := path[1:]; rest != ""; {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			segments = append(segments, rest[1:end+1])
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, `["`):
+			quoted, err := strconv.QuotedPrefix(rest[1:])
+			if err != nil {
+				return nil, false
+			}
+			key, _ := strconv.Unquote(quoted)
+			segments = append(segments, key)
+			rest = strings.TrimPrefix(rest[1+len(quoted):], "]")
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, false
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, false
+			}
+			segments = append(segments, index)
+			rest = rest[end+1:]
+		default:
+			return nil, false
+		}
+	}
+	return segments, true
+}
+
+// PathKeys returns the keys at the beginning of the json path, up to the first array index,
+// e.g. the keys of $.ServiceName.limit.qps_limit for FileWatcher.Origin.
+func PathKeys(path string) []string {
+	segments, _ := splitPath(path)
+	keys := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		key, ok := segment.(string)
+		if !ok {
+			break
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// jsonScanner finds the offsets of values in a json document without decoding it.
+type jsonScanner struct {
+	data []byte
+	pos  int
+}
+
+// locate returns the offset of the key, or the array item, at the path from the value at pos.
+func (s *jsonScanner) locate(segments []interface{}) (int, bool) {
+	s.skipSpace()
+	if len(segments) == 0 || s.pos >= len(s.data) {
+		return s.pos, len(segments) == 0
+	}
+	switch s.data[s.pos] {
+	case '{':
+		key, ok := segments[0].(string)
+		if !ok {
+			return 0, false
+		}
+		s.pos++
+		for {
+			s.skipSpace()
+			start := s.pos
+			name, ok := s.readString()
+			if !ok {
+				return 0, false
+			}
+			s.skipSpace()
+			if !s.consume(':') {
+				return 0, false
+			}
+			if name == key {
+				if len(segments) == 1 {
+					return start, true
+				}
+				return s.locate(segments[1:])
+			}
+			if !s.skipValue() {
+				return 0, false
+			}
+			s.skipSpace()
+			if !s.consume(',') {
+				return 0, false
+			}
+		}
+	case '[':
+		index, ok := segments[0].(int)
+		if !ok {
+			return 0, false
+		}
+		s.pos++
+		for i := 0; ; i++ {
+			s.skipSpace()
+			if i == index {
+				if len(segments) == 1 {
+					return s.pos, true
+				}
+				return s.locate(segments[1:])
+			}
+			if !s.skipValue() {
+				return 0, false
+			}
+			s.skipSpace()
+			if !s.consume(',') {
+				return 0, false
+			}
+		}
+	}
+	return 0, false
+}
+
+func (s *jsonScanner) skipSpace() {
+	for s.pos < len(s.data) && strings.IndexByte(" \t\r\n", s.data[s.pos]) >= 0 {
+		s.pos++
+	}
+}
+
+func (s *jsonScanner) consume(c byte) bool {
+	if s.pos < len(s.data) && s.data[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+// readString reads the string at pos.
+func (s *jsonScanner) readString() (string, bool) {
+	start := s.pos
+	if !s.skipString() {
+		return "", false
+	}
+	var str string
+	return str, json.Unmarshal(s.data[start:s.pos], &str) == nil
+}
+
+func (s *jsonScanner) skipString() bool {
+	if !s.consume('"') {
+		return false
+	}
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos += 2
+		case '"':
+			s.pos++
+			return true
+		default:
+			s.pos++
+		}
+	}
+	return false
+}
+
+// skipValue skips the value at pos.
+func (s *jsonScanner) skipValue() bool {
+	depth := 0
+	for s.pos < len(s.data) {
+		switch c := s.data[s.pos]; c {
+		case '"':
+			if !s.skipString() {
+				return false
+			}
+			if depth == 0 {
+				return true
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return true // the end of the parent
+			}
+			depth--
+			if depth == 0 {
+				s.pos++
+				return true
+			}
+		case ',':
+			if depth == 0 {
+				return true
+			}
+		}
+		s.pos++
+	}
+	return depth == 0
+}
+
+// locateYAML returns the line and column of the key, or the sequence item, at the path in node.
+func locateYAML(node *yamlv3.Node, segments []interface{}) (int, int, bool) {
+	for node.Kind == yamlv3.DocumentNode || node.Kind == yamlv3.AliasNode {
+		if node.Kind == yamlv3.AliasNode {
+			node = node.Alias
+		} else if len(node.Content) > 0 {
+			node = node.Content[0]
+		} else {
+			return 0, 0, false
+		}
+	}
+	if len(segments) == 0 {
+		return node.Line, node.Column, true
+	}
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		key, ok := segments[0].(string)
+		if !ok {
+			return 0, 0, false
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if k := node.Content[i]; k.Value == key {
+				if len(segments) == 1 {
+					return k.Line, k.Column, true
+				}
+				return locateYAML(node.Content[i+1], segments[1:])
+			}
+		}
+	case yamlv3.SequenceNode:
+		index, ok := segments[0].(int)
+		if ok && index < len(node.Content) {
+			return locateYAML(node.Content[index], segments[1:])
+		}
+	}
+	return 0, 0, false
+}
+
+// Excerpt returns the snippet with a caret under the column, for logs.
+func (p Position) Excerpt() string {
+	if p.Snippet == "" {
+		return ""
+	}
+	if p.Column <= 0 || p.Column > len(p.Snippet)+1 {
+		return p.Snippet
+	}
+	return fmt.Sprintf("%s\n%s^", p.Snippet, strings.Repeat(" ", p.Column-1))
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	err := DefaultConfigParser().Decode(JSON, []byte("{\n  \"Test1\": {\n    \"limit\": x\n  }\n}"), &ServerFileManager{})
+	var de *DecodeError
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, 3, de.Line)
+	assert.Equal(t, 14, de.Column)
+	assert.Equal(t, `    "limit": x`, de.Snippet)
+	assert.Equal(t, "    \"limit\": x\n             ^", de.Excerpt())
+
+	err = DefaultConfigParser().Decode(YAML, []byte("Test1: {}\n---\nTest2:\n  limit: [\n"), &ServerFileManager{})
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, 4, de.Line)
+}
+
+func TestValidationErrorPosition(t *testing.T) {
+	data := []byte(`{
+  "Test1": {"limit": {"qps_limit": 1}},
+  "Test2": {
+    "limit": {"connection_limit": 1, "qps_limit": "invalid"}
+  }
+}`)
+	err := DefaultConfigParser().Decode(JSON, data, &ServerFileManager{})
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, Position{Line: 4, Column: 38, Snippet: `    "limit": {"connection_limit": 1, "qps_limit": "invalid"}`}, errs[0].Position)
+
+	// located in the whole file when only the key is decoded
+	err = DefaultConfigParser().(KeyDecoder).DecodeKeys(JSON, data, []string{"Test2"}, &ServerFileManager{})
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, 4, errs[0].Line)
+
+	yaml := []byte("Test1:\n  limit:\n    qps_limit: 1\n---\nTest2:\n  limit:\n    qps_limit: invalid\n")
+	err = DefaultConfigParser().Decode(YAML, yaml, &ServerFileManager{})
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, Position{Line: 7, Column: 5, Snippet: "    qps_limit: invalid"}, errs[0].Position)
+}
+
+func TestSplitPath(t *testing.T) {
+	segments, ok := splitPath(`$["Client/Service"].retry.Echo[2]["a\"b"]`)
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{"Client/Service", "retry", "Echo", 2, `a"b`}, segments)
+	assert.Equal(t, []string{"Client/Service", "retry", "Echo"}, PathKeys(`$["Client/Service"].retry.Echo[2]["a\"b"]`))
+
+	_, ok = splitPath("retry.Echo")
+	assert.False(t, ok)
+}
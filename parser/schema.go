@@ -0,0 +1,591 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/kitex/pkg/retry"
+)
+
+const schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is the subset of JSON Schema (draft-07) used to describe and validate config files.
+type Schema struct {
+	SchemaURI            string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // false or *Schema
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+
+	pattern  *regexp.Regexp // compiled Pattern
+	duration bool           // a number of milliseconds, which also accepts duration strings
+}
+
+var (
+	schemaCache      sync.Map // reflect.Type -> *Schema
+	enumCheckerCache sync.Map // reflect.Type -> enumChecker
+
+	// enums lists the allowed values of the enum-like types used in the config.
+	enums = map[reflect.Type][]interface{}{
+		reflect.TypeOf(retry.Type(0)): {int(retry.FailureType), int(retry.BackupType)},
+		reflect.TypeOf(retry.BackOffType("")): {
+			string(retry.NoneBackOffType), string(retry.FixedBackOffType), string(retry.RandomBackOffType),
+		},
+		reflect.TypeOf(retry.BackOffCfgKey("")): {
+			string(retry.FixMSBackOffCfgKey), string(retry.MinMSBackOffCfgKey), string(retry.MaxMSBackOffCfgKey),
+			string(retry.InitialMSBackOffCfgKey), string(retry.MultiplierBackOffCfgKey),
+		},
+	}
+
+	// descriptions are shown by editors when completing the field with the same name.
+	descriptions = map[string]string{
+		"timeout":            "RPC timeout policies, key: method, \"*\" for default",
+		"retry":              "retry policies, key: method, \"*\" for default",
+		"circuitbreaker":     "circuit breaker policies, key: method",
+		"limit":              "server limiter, zero value means no limit",
+		"extends":            "key of the config in the same file to inherit the policies from",
+		"rpc_timeout_ms":     "RPC timeout in milliseconds",
+		"conn_timeout_ms":    "connection timeout in milliseconds",
+		"enable":             "whether the policy is enabled",
+		"type":               "0: failure_policy, 1: backup_policy",
+		"failure_policy":     "retry on failure, used when type is 0",
+		"backup_policy":      "send a backup request, used when type is 1",
+		"retry_delay_ms":     "delay before sending the backup request in milliseconds",
+		"max_retry_times":    "maximum retry times",
+		"max_duration_ms":    "maximum total duration of the call and its retries in milliseconds",
+		"error_rate":         "stop retrying when the error rate exceeds this value",
+		"backoff_type":       "one of none, fixed and random",
+		"err_rate":           "error rate which opens the circuit breaker",
+		"min_sample":         "minimum statistical sample number",
+		"connection_limit":   "maximum concurrent connections",
+		"qps_limit":          "maximum request number every 100ms",
+		"retry_same_node":    "whether to retry on the same node",
+		"disable_chain_stop": "disable the chain stop of retry",
+		"ddl_stop":           "stop retrying when the deadline is exceeded",
+	}
+)
+
+// GenerateSchema generates the schema of the type of v by reflection, following its json tags.
+// The returned schema is shared and must not be modified.
+func GenerateSchema(v interface{}) *Schema {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+// ClientFileSchema returns the schema of the client config file, see ClientFileManager.
+func ClientFileSchema() *Schema {
+	s := *GenerateSchema(ClientFileManager{})
+	s.SchemaURI = schemaDraft
+	s.Properties = map[string]*Schema{VersionKey: versionSchema()}
+	s.Title = "kitex client config file"
+	s.Description = "key: ClientName/ServiceName"
+	return &s
+}
+
+// ServerFileSchema returns the schema of the server config file, see ServerFileManager.
+func ServerFileSchema() *Schema {
+	s := *GenerateSchema(ServerFileManager{})
+	s.SchemaURI = schemaDraft
+	s.Properties = map[string]*Schema{VersionKey: versionSchema()}
+	s.Title = "kitex server config file"
+	s.Description = "key: ServiceName"
+	return &s
+}
+
+// versionSchema is the schema of VersionKey at the top level of the files.
+func versionSchema() *Schema {
+	minimum := float64(InitialVersion)
+	return &Schema{Description: "format version of the file, older versions are migrated when loaded", Type: "integer", Minimum: &minimum}
+}
+
+// JSON returns the indented schema document, which can be used by editors for validation and autocompletion.
+func (s *Schema) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if s, ok := schemaCache.Load(t); ok {
+		return s.(*Schema)
+	}
+	s := generate(t, map[reflect.Type]bool{})
+	if s == nil {
+		s = &Schema{}
+	}
+	schemaCache.Store(t, s)
+	return s
+}
+
+// generate returns nil for types which can not be represented in json, such as func.
+func generate(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if visiting[t] {
+		return &Schema{} // recursive type, accept anything
+	}
+
+	var s *Schema
+	switch t.Kind() {
+	case reflect.Bool:
+		s = &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := float64(0)
+		s = &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		s = &Schema{Type: "number"}
+	case reflect.String:
+		s = &Schema{Type: "string"}
+	case reflect.Interface:
+		s = &Schema{}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			s = &Schema{Type: "string"} // []byte is base64 encoded
+			break
+		}
+		visiting[t] = true
+		s = &Schema{Type: "array", Items: generate(t.Elem(), visiting)}
+		delete(visiting, t)
+	case reflect.Map:
+		visiting[t] = true
+		s = &Schema{Type: "object", AdditionalProperties: generate(t.Elem(), visiting)}
+		delete(visiting, t)
+		if keys, ok := enums[t.Key()]; ok {
+			s.PropertyNames = &Schema{Enum: keys}
+			if vs, ok := s.AdditionalProperties.(*Schema); ok {
+				s.Properties = make(map[string]*Schema, len(keys))
+				for _, key := range keys {
+					s.Properties[key.(string)] = fieldSchema(key.(string), vs)
+				}
+			}
+		}
+	case reflect.Struct:
+		visiting[t] = true
+		s = &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+		addFields(s, t, visiting)
+		delete(visiting, t)
+	default:
+		return nil
+	}
+
+	if values, ok := enums[t]; ok {
+		s.Enum = values
+	}
+	return s
+}
+
+// addFields adds the exported fields of struct t to the properties of s, embedded structs are flattened.
+func addFields(s *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && field.Tag.Get("json") == "" && ft.Kind() == reflect.Struct {
+			addFields(s, ft, visiting)
+			continue
+		}
+		fs := generate(field.Type, visiting)
+		if fs == nil {
+			continue
+		}
+		s.Properties[name] = fieldSchema(name, fs)
+	}
+}
+
+// fieldSchema returns the schema of the field with the given name, adding its description
+// and the duration string form of the millisecond fields.
+func fieldSchema(name string, s *Schema) *Schema {
+	if isMillisecond(name, s) {
+		s = durationSchema(s)
+	}
+	if desc, ok := descriptions[name]; ok && s.Description == "" {
+		copied := *s
+		copied.Description = desc
+		s = &copied
+	}
+	return s
+}
+
+// fieldName returns the key of the field in the config file.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "mapstructure"} {
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// Validate checks doc, a decoded json/yaml document, against the schema.
+// It returns the invalid values, and separately the json paths of the fields unknown to the schema.
+func (s *Schema) Validate(doc interface{}) (errs ValidationErrors, unknown []string) {
+	c := &schemaCheck{}
+	s.check(doc, c)
+	c.sort()
+	return c.errs, c.unknown
+}
+
+// validateAndNormalize is Validate which also converts the duration strings of the millisecond fields
+// into numbers of milliseconds in place, in the same walk. It reports whether doc is changed.
+func (s *Schema) validateAndNormalize(doc interface{}) (interface{}, bool, ValidationErrors, []string) {
+	c := &schemaCheck{normalize: true}
+	doc, changed := s.check(doc, c)
+	c.sort()
+	return doc, changed, c.errs, c.unknown
+}
+
+// schemaCheck collects the results of a walk of a document.
+type schemaCheck struct {
+	normalize bool       // convert the duration strings
+	path      []pathElem // of the current value, only formatted when reported
+	errs      ValidationErrors
+	unknown   []string
+}
+
+// pathElem is a key of an object, or the index of an array item if key is empty.
+type pathElem struct {
+	key   string
+	index int
+}
+
+// sort sorts the results by path, as the keys of the objects are walked in random order.
+func (c *schemaCheck) sort() {
+	sort.SliceStable(c.errs, func(i, j int) bool { return c.errs[i].Path < c.errs[j].Path })
+	sort.Strings(c.unknown)
+}
+
+// pathString returns the json path of the current value, e.g. $.retry["*"].type
+func (c *schemaCheck) pathString() string {
+	path := "$"
+	for _, e := range c.path {
+		if e.key == "" {
+			path = fmt.Sprintf("%s[%d]", path, e.index)
+			continue
+		}
+		path = JoinPath(path, e.key)
+	}
+	return path
+}
+
+func (c *schemaCheck) fail(format string, args ...interface{}) {
+	c.errs = append(c.errs, &FieldError{Path: c.pathString(), Message: fmt.Sprintf(format, args...)})
+}
+
+// check validates value at the current path, and returns it normalized if it is changed.
+func (s *Schema) check(value interface{}, c *schemaCheck) (interface{}, bool) {
+	if s == nil || value == nil {
+		return value, false // null leaves the field as zero value
+	}
+	if len(s.AnyOf) > 0 && !s.checkAnyOf(value, c) {
+		return value, false
+	}
+	if s.Type != "" && !matchType(s.Type, value) {
+		c.fail("expected %s, got %s", s.Type, describe(value))
+		return value, false
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		c.fail("expected one of %s, got %s", enumString(s.Enum), describe(value))
+		return value, false
+	}
+	if s.Minimum != nil {
+		if f, ok := toFloat(value); ok && f < *s.Minimum {
+			c.fail("must be at least %v, got %v", *s.Minimum, f)
+		}
+	}
+	if str, ok := value.(string); ok && s.pattern != nil && !s.pattern.MatchString(str) {
+		c.fail("must match %s, got %q", s.Pattern, str)
+	}
+	if s.duration && c.normalize {
+		return normalizeDuration(value, c)
+	}
+
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			c.path = append(c.path, pathElem{key: key})
+			if nv, ok := s.checkProperty(key, item, c); ok {
+				v[key] = nv
+				changed = true
+			}
+			c.path = c.path[:len(c.path)-1]
+		}
+	case []interface{}:
+		for i, item := range v {
+			c.path = append(c.path, pathElem{index: i})
+			if nv, ok := s.Items.check(item, c); ok {
+				v[i] = nv
+				changed = true
+			}
+			c.path = c.path[:len(c.path)-1]
+		}
+	}
+	return value, changed
+}
+
+// checkProperty checks the value of key in an object, the current path is the one of the value.
+func (s *Schema) checkProperty(key string, value interface{}, c *schemaCheck) (interface{}, bool) {
+	if s.PropertyNames != nil && !inEnum(s.PropertyNames.Enum, key) {
+		c.fail("key must be one of %s", enumString(s.PropertyNames.Enum))
+		return value, false
+	}
+	if ps, ok := s.Properties[key]; ok {
+		return ps.check(value, c)
+	}
+	switch ap := s.AdditionalProperties.(type) {
+	case *Schema:
+		return ap.check(value, c)
+	case bool:
+		if !ap {
+			c.unknown = append(c.unknown, c.pathString())
+		}
+	}
+	return value, false
+}
+
+// checkAnyOf reports an error unless value is valid against at least one of the alternatives.
+func (s *Schema) checkAnyOf(value interface{}, c *schemaCheck) bool {
+	for _, alt := range s.AnyOf {
+		sub := schemaCheck{}
+		alt.check(value, &sub)
+		if len(sub.errs) == 0 {
+			if len(sub.unknown) > 0 {
+				path := c.pathString()
+				for _, u := range sub.unknown {
+					c.unknown = append(c.unknown, path+strings.TrimPrefix(u, "$"))
+				}
+			}
+			return true
+		}
+	}
+	titles := make([]string, 0, len(s.AnyOf))
+	for _, alt := range s.AnyOf {
+		title := alt.Title
+		if title == "" {
+			title = alt.Type
+		}
+		titles = append(titles, title)
+	}
+	c.fail("expected %s, got %s", strings.Join(titles, " or "), describe(value))
+	return false
+}
+
+func matchType(typ string, value interface{}) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	}
+	return true
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if ef, ok := toFloat(e); ok {
+			if vf, ok := toFloat(value); ok && ef == vf {
+				return true
+			}
+			continue
+		}
+		if e == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validEnums reports whether the values of the enum-like types in v, a decoded config, are allowed,
+// which is the only check of the schema that a strict typed decoding does not do itself.
+func validEnums(v reflect.Value) bool {
+	check := enumCheckerOf(v.Type())
+	return check == nil || check(v)
+}
+
+// enumChecker reports whether the enum-like values in a value of some type are allowed.
+type enumChecker func(v reflect.Value) bool
+
+// enumCheckerOf returns the cached checker of t, nil if its values contain no enum-like types.
+func enumCheckerOf(t reflect.Type) enumChecker {
+	if check, ok := enumCheckerCache.Load(t); ok {
+		return check.(enumChecker)
+	}
+	check := newEnumChecker(t, map[reflect.Type]bool{})
+	enumCheckerCache.Store(t, check)
+	return check
+}
+
+func newEnumChecker(t reflect.Type, visiting map[reflect.Type]bool) enumChecker {
+	if allowed, ok := enums[t]; ok {
+		return func(v reflect.Value) bool { return inEnum(allowed, enumValue(v)) }
+	}
+	if visiting[t] {
+		return nil // recursive types are not used by the configs
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return func(v reflect.Value) bool { return v.IsNil() || validEnums(v.Elem()) }
+	case reflect.Ptr:
+		if elem := newEnumChecker(t.Elem(), visiting); elem != nil {
+			return func(v reflect.Value) bool { return v.IsNil() || elem(v.Elem()) }
+		}
+	case reflect.Slice, reflect.Array:
+		if elem := newEnumChecker(t.Elem(), visiting); elem != nil {
+			return func(v reflect.Value) bool {
+				for i := 0; i < v.Len(); i++ {
+					if !elem(v.Index(i)) {
+						return false
+					}
+				}
+				return true
+			}
+		}
+	case reflect.Map:
+		keys, checkKeys := enums[t.Key()]
+		elem := newEnumChecker(t.Elem(), visiting)
+		if checkKeys || elem != nil {
+			return func(v reflect.Value) bool {
+				for iter := v.MapRange(); iter.Next(); {
+					if checkKeys && !inEnum(keys, enumValue(iter.Key())) || elem != nil && !elem(iter.Value()) {
+						return false
+					}
+				}
+				return true
+			}
+		}
+	case reflect.Struct:
+		var fields []int
+		var checks []enumChecker
+		for i := 0; i < t.NumField(); i++ {
+			if check := newEnumChecker(t.Field(i).Type, visiting); check != nil {
+				fields, checks = append(fields, i), append(checks, check)
+			}
+		}
+		if len(fields) > 0 {
+			return func(v reflect.Value) bool {
+				for i, field := range fields {
+					if !checks[i](v.Field(field)) {
+						return false
+					}
+				}
+				return true
+			}
+		}
+	}
+	return nil
+}
+
+// enumValue returns v as the values listed in enums.
+func enumValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int())
+	case reflect.String:
+		return v.String()
+	}
+	return v.Interface()
+}
+
+func enumString(enum []interface{}) string {
+	values := make([]string, 0, len(enum))
+	for _, e := range enum {
+		if str, ok := e.(string); ok {
+			values = append(values, strconv.Quote(str))
+			continue
+		}
+		values = append(values, fmt.Sprint(e))
+	}
+	return strings.Join(values, ", ")
+}
+
+func describe(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	}
+	if f, ok := toFloat(value); ok {
+		return fmt.Sprintf("number %v", f)
+	}
+	return fmt.Sprintf("%T", value)
+}
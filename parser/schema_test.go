@@ -0,0 +1,97 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// run `go test ./parser -run TestSchemaFiles -update` after changing the config types.
+var update = flag.Bool("update", false, "update the schema files")
+
+func TestSchemaFiles(t *testing.T) {
+	for path, schema := range map[string]*Schema{
+		"./../schema/kitex_client.schema.json": ClientFileSchema(),
+		"./../schema/kitex_server.schema.json": ServerFileSchema(),
+	} {
+		data, err := schema.JSON()
+		assert.Nil(t, err)
+		data = append(data, '\n')
+
+		if *update {
+			assert.Nil(t, os.WriteFile(path, data, 0o644))
+			continue
+		}
+		want, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, string(want), string(data), "%s is outdated, run the test with -update", path)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	data := []byte(`{
+		"ClientName/ServiceName": {
+			"timeout": {"*": {"rpc_timeout": 100}},
+			"retry": {"Echo": {"enable": true, "type": "backup"}},
+			"circuitbreaker": {"Echo": {"min_sample": "100"}}
+		}
+	}`)
+	var doc interface{}
+	assert.Nil(t, DefaultConfigParser().Decode(JSON, data, &doc))
+
+	errs, unknown := ClientFileSchema().Validate(doc)
+	assert.Equal(t, []string{`$["ClientName/ServiceName"].timeout["*"].rpc_timeout`}, unknown)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, `$["ClientName/ServiceName"].circuitbreaker.Echo.min_sample`, errs[0].Path)
+	assert.Equal(t, `$["ClientName/ServiceName"].retry.Echo.type`, errs[1].Path)
+
+	manager := ClientFileManager{}
+	assert.NotNil(t, DefaultConfigParser().Decode(JSON, data, &manager))
+}
+
+func TestValidateAndNormalize(t *testing.T) {
+	var doc interface{}
+	assert.Nil(t, DefaultConfigParser().Decode(JSON, []byte(`{"Client": {"timeout": {"Echo": {"rpc_timeout_ms": "2s", "conn_timeout_ms": 50}}}}`), &doc))
+	doc, changed, errs, unknown := ClientFileSchema().validateAndNormalize(doc)
+	assert.True(t, changed)
+	assert.Empty(t, errs)
+	assert.Empty(t, unknown)
+	assert.Equal(t, float64(2000), doc.(map[string]interface{})["Client"].(map[string]interface{})["timeout"].(map[string]interface{})["Echo"].(map[string]interface{})["rpc_timeout_ms"])
+
+	assert.Nil(t, DefaultConfigParser().Decode(JSON, []byte(`{"Client": {"timeout": {"Echo": {"rpc_timeout_ms": "1.5ms"}, "Get-1": {"conn_timeout_ms": 50}}}}`), &doc))
+	_, changed, errs, _ = ClientFileSchema().validateAndNormalize(doc)
+	assert.False(t, changed)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, `$.Client.timeout.Echo.rpc_timeout_ms`, errs[0].Path)
+
+	assert.Equal(t, `$.a_1`, JoinPath("", "a_1"))
+	assert.Equal(t, `$["1a"]`, JoinPath("", "1a"))
+	assert.Equal(t, `$[""]`, JoinPath("", ""))
+}
+
+func TestStrictParser(t *testing.T) {
+	data := []byte(`{"ClientName/ServiceName": {"circuit_breaker": {"Echo": {"enable": true}}}}`)
+
+	manager := ClientFileManager{}
+	assert.Nil(t, DefaultConfigParser().Decode(JSON, data, &manager))
+	assert.Empty(t, manager["ClientName/ServiceName"].Circuitbreaker)
+
+	err := StrictParser(DefaultConfigParser()).Decode(JSON, data, &ClientFileManager{})
+	assert.Equal(t, `$["ClientName/ServiceName"].circuit_breaker: unknown field`, err.Error())
+}
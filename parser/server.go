@@ -14,23 +14,111 @@
 
 package parser
 
-import "github.com/cloudwego/kitex/pkg/limiter"
+import (
+	"reflect"
+	"strings"
+
+	"github.com/cloudwego/kitex/pkg/limiter"
+)
 
 // ServerFileConfig is config of a service
 type ServerFileConfig struct {
-	Limit limiter.LimiterConfig `mapstructure:"limit"`
+	Limit   limiter.LimiterConfig `json:"limit" mapstructure:"limit"`
+	Extends string                `json:"extends" mapstructure:"extends"` // key of the config to inherit
+
+	doc map[string]interface{} // the fields present in the file, kept if the keys are merged, see documentOf
 }
 
 // ServerFileManager is a map of service names to ServerFileConfig
 type ServerFileManager map[string]*ServerFileConfig
 
-// GetConfig returns the config from Manager by key
+// GetConfig returns the config from Manager by key, the global "*" config is used if the key is absent.
 func (s *ServerFileManager) GetConfig(key string) interface{} {
-	config, exist := (*s)[key]
+	var config *ServerFileConfig
+	for _, pattern := range serverKeyPatterns(key) {
+		if c := (*s)[pattern]; c != nil {
+			config = mergeServerConfig(config, c)
+		}
+	}
 
-	if !exist {
+	if config == nil {
 		return nil
 	}
 
 	return config
 }
+
+// ExactConfig returns the config of the key as written in the file, without the wildcard keys merged.
+func (s *ServerFileManager) ExactConfig(key string) interface{} {
+	if c := (*s)[key]; c != nil {
+		return c
+	}
+	return nil
+}
+
+// WildcardConfig returns the merged configs of the wildcard keys matching the key, without the key itself.
+func (s *ServerFileManager) WildcardConfig(key string) interface{} {
+	var config *ServerFileConfig
+	for _, pattern := range s.WildcardKeys(key) {
+		if c := (*s)[pattern]; c != nil {
+			config = mergeServerConfig(config, c)
+		}
+	}
+
+	if config == nil {
+		return nil
+	}
+
+	return config
+}
+
+// WildcardKeys returns the wildcard keys matching the key, from the least specific to the most specific.
+func (s *ServerFileManager) WildcardKeys(key string) []string {
+	var keys []string
+	for _, pattern := range serverKeyPatterns(key) {
+		if pattern != key {
+			keys = append(keys, pattern)
+		}
+	}
+	return keys
+}
+
+// keepsDocuments reports whether the configs are merged with other keys, by the wildcard keys or extends.
+func (s *ServerFileManager) keepsDocuments() bool {
+	for key, c := range *s {
+		if strings.Contains(key, Wildcard) || c != nil && c.Extends != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ServerFileManager) keepDocuments(doc map[string]interface{}) {
+	for key, c := range *s {
+		if c != nil {
+			c.doc, _ = canonicalDocument(doc[key], reflect.TypeOf(c)).(map[string]interface{})
+		}
+	}
+}
+
+// DefaultConfig returns an empty config, with which the suite restores the defaults of kitex
+func (s *ServerFileManager) DefaultConfig() interface{} { return &ServerFileConfig{} }
+
+// ExtendsKey returns the key of the config to inherit
+func (c *ServerFileConfig) ExtendsKey() string { return c.Extends }
+
+// Inherit returns a new config with the limit of parent merged field by field, the fields it sets take precedence
+func (c *ServerFileConfig) Inherit(parent interface{}) interface{} {
+	p, _ := parent.(*ServerFileConfig)
+	merged := mergeServerConfig(p, c)
+	if merged == c {
+		copied := *c
+		merged = &copied
+	}
+	merged.Extends = ""
+	if _, ok := merged.doc[ExtendsField]; ok {
+		merged.doc = copyDocument(merged.doc)
+		delete(merged.doc, ExtendsField)
+	}
+	return merged
+}
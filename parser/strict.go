@@ -0,0 +1,53 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import "reflect"
+
+type strictParser struct {
+	parser ConfigParser
+}
+
+// StrictParser wraps p so that fields unknown to the config type fail the decoding,
+// e.g. a misspelled "circuit_breaker" category. The errors contain the json path of each unknown field.
+func StrictParser(p ConfigParser) ConfigParser {
+	if _, ok := p.(*strictParser); ok {
+		return p
+	}
+	return &strictParser{parser: p}
+}
+
+// Decode decodes the data to struct in specified format, rejecting unknown fields.
+func (s *strictParser) Decode(kind ConfigType, data []byte, config interface{}) error {
+	var doc interface{}
+	if err := s.parser.Decode(kind, data, &doc); err != nil {
+		return err
+	}
+
+	// check the fields of the current version, the warnings are logged by the wrapped parser
+	doc, _, err := migrate(reflect.TypeOf(config), doc, false)
+	if err != nil {
+		return err
+	}
+	if _, unknown := schemaOf(reflect.TypeOf(config)).Validate(doc); len(unknown) > 0 {
+		errs := make(ValidationErrors, 0, len(unknown))
+		for _, path := range unknown {
+			errs = append(errs, &FieldError{Path: path, Message: "unknown field"})
+		}
+		return errs
+	}
+
+	return s.parser.Decode(kind, data, config)
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// InstanceIndexEnv is the environment variable of the index of the instance, see TemplateData.Instance.
+const InstanceIndexEnv = "INSTANCE_INDEX"
+
+// TemplateData is the data of the config templates, e.g. "qps_limit": {{ mul .CPUs 50 }}
+type TemplateData struct {
+	Hostname string
+	CPUs     int
+	// Instance is the index of the instance, read from INSTANCE_INDEX,
+	// or the ordinal suffix of the hostname such as "web-2" of a kubernetes StatefulSet, 0 by default.
+	Instance int
+	Env      map[string]string
+}
+
+var ordinalSuffix = regexp.MustCompile(`-(\d+)$`)
+
+// NewTemplateData collects the facts of the host.
+func NewTemplateData() *TemplateData {
+	hostname, _ := os.Hostname()
+	data := &TemplateData{
+		Hostname: hostname,
+		CPUs:     runtime.NumCPU(),
+		Env:      make(map[string]string),
+	}
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			data.Env[k] = v
+		}
+	}
+	if index, err := strconv.Atoi(data.Env[InstanceIndexEnv]); err == nil {
+		data.Instance = index
+	} else if m := ordinalSuffix.FindStringSubmatch(hostname); m != nil {
+		data.Instance, _ = strconv.Atoi(m[1])
+	}
+	return data
+}
+
+// templateFuncs are the functions available to the config templates besides the builtin ones of text/template.
+func templateFuncs(data *TemplateData) template.FuncMap {
+	return template.FuncMap{
+		"hostname": func() string { return data.Hostname },
+		"cpus":     func() int { return data.CPUs },
+		"instance": func() int { return data.Instance },
+		"env": func(key string, def ...string) string {
+			if v, ok := data.Env[key]; ok || len(def) == 0 {
+				return v
+			}
+			return def[0]
+		},
+		"add": func(a, b int) int { return a + b },
+		"sub": func(a, b int) int { return a - b },
+		"mul": func(a, b int) int { return a * b },
+		"div": func(a, b int) (int, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a / b, nil
+		},
+		"max": func(a, b int) int {
+			if a > b {
+				return a
+			}
+			return b
+		},
+		"min": func(a, b int) int {
+			if a < b {
+				return a
+			}
+			return b
+		},
+	}
+}
+
+// RenderTemplate renders the config file as a Go template with data.
+func RenderTemplate(content []byte, data *TemplateData) ([]byte, error) {
+	tmpl, err := template.New("config").Option("missingkey=error").Funcs(templateFuncs(data)).Parse(string(content))
+	if err != nil {
+		return nil, templateError(content, fmt.Errorf("parse config template failed: %w", err))
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, templateError(content, fmt.Errorf("render config template failed: %w", err))
+	}
+	return buf.Bytes(), nil
+}
+
+// templateLine matches the position in the errors of text/template, e.g. "template: config:3:15: ...",
+// the column is only reported by the errors of executing, from 0.
+var templateLine = regexp.MustCompile(`template: config:(\d+):(?:(\d+):)?`)
+
+// templateError returns the DecodeError of err, the error of parsing or executing the template content.
+func templateError(content []byte, err error) error {
+	var pos Position
+	if m := templateLine.FindStringSubmatch(err.Error()); m != nil {
+		pos.Line, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			column, _ := strconv.Atoi(m[2])
+			pos.Column = column + 1
+		}
+		pos.Snippet = lineAt(content, pos.Line)
+	}
+	return &DecodeError{Position: pos, Err: err}
+}
+
+type templateParser struct {
+	parser ConfigParser
+}
+
+// TemplateParser wraps p so that the config file is rendered as a Go template before decoding,
+// with the data of NewTemplateData and the functions hostname, cpus, instance, env, add, sub, mul, div, max and min.
+// The errors of rendering fail the decoding.
+func TemplateParser(p ConfigParser) ConfigParser {
+	if _, ok := p.(*templateParser); ok {
+		return p
+	}
+	return &templateParser{parser: p}
+}
+
+// Decode renders the data and decodes the result with the wrapped parser.
+func (t *templateParser) Decode(kind ConfigType, data []byte, config interface{}) error {
+	rendered, err := RenderTemplate(data, NewTemplateData())
+	if err != nil {
+		return err
+	}
+	return t.parser.Decode(kind, rendered, config)
+}
+
+// DecodeKeys renders the data and decodes the configs of keys with the wrapped parser, see KeyDecoder.
+func (t *templateParser) DecodeKeys(kind ConfigType, data []byte, keys []string, config interface{}) error {
+	rendered, err := RenderTemplate(data, NewTemplateData())
+	if err != nil {
+		return err
+	}
+	if d, ok := t.parser.(KeyDecoder); ok {
+		return d.DecodeKeys(kind, rendered, keys, config)
+	}
+	return t.parser.Decode(kind, rendered, config)
+}
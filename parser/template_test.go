@@ -0,0 +1,63 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	data := &TemplateData{Hostname: "web-2", CPUs: 4, Instance: 2, Env: map[string]string{"ZONE": "a"}}
+	out, err := RenderTemplate([]byte(`{{ mul .CPUs 50 }} {{ hostname }} {{ add instance 1 }} {{ env "ZONE" }} {{ env "REGION" "cn" }} {{ .Env.ZONE }}`), data)
+	assert.Nil(t, err)
+	assert.Equal(t, "200 web-2 3 a cn a", string(out))
+
+	_, err = RenderTemplate([]byte(`{{ div .CPUs 0 }}`), data)
+	assert.ErrorContains(t, err, "division by zero")
+	_, err = RenderTemplate([]byte(`{{ mul .CPUs }`), data)
+	assert.ErrorContains(t, err, "parse config template failed")
+}
+
+func TestTemplateErrorPosition(t *testing.T) {
+	data := &TemplateData{CPUs: 4}
+	_, err := RenderTemplate([]byte("{\n  \"qps_limit\": {{ div .CPUs 0 }}\n}"), data)
+	var de *DecodeError
+	assert.ErrorAs(t, err, &de)
+	assert.Equal(t, 2, de.Line)
+	assert.Equal(t, 19, de.Column) // at div
+	assert.Equal(t, `  "qps_limit": {{ div .CPUs 0 }}`, de.Snippet)
+
+	_, err = RenderTemplate([]byte("{\n\n  \"qps_limit\": {{ mul .CPUs }\n}"), data)
+	assert.ErrorAs(t, err, &de)
+	assert.Equal(t, 3, de.Line)
+	assert.Equal(t, `  "qps_limit": {{ mul .CPUs }`, de.Snippet)
+	assert.ErrorContains(t, err, "parse config template failed")
+}
+
+func TestTemplateParser(t *testing.T) {
+	t.Setenv(InstanceIndexEnv, "3")
+	data := []byte(`{"ServiceName": {"limit": {"qps_limit": {{ mul .CPUs 50 }}, "connection_limit": {{ add .Instance 100 }}}}}`)
+
+	manager := ServerFileManager{}
+	assert.Nil(t, TemplateParser(DefaultConfigParser()).Decode(JSON, data, &manager))
+	assert.Equal(t, int64(runtime.NumCPU()*50), manager["ServiceName"].Limit.QPSLimit)
+	assert.Equal(t, int64(103), manager["ServiceName"].Limit.ConnectionLimit)
+
+	err := TemplateParser(DefaultConfigParser()).Decode(JSON, []byte(`{{ .Unknown }}`), &manager)
+	assert.ErrorContains(t, err, "render config template failed")
+}
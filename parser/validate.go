@@ -0,0 +1,178 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudwego/kitex/pkg/circuitbreak"
+	"github.com/cloudwego/kitex/pkg/retry"
+	"github.com/cloudwego/kitex/pkg/rpctimeout"
+)
+
+const (
+	// limits of kitex, see retry.failureRetryer and retry.backupRetryer
+	maxFailureRetryTimes = 5
+	maxBackupRetryTimes  = 2
+	maxRetryErrorRate    = 0.3
+)
+
+// Validator is implemented by configs which check the semantics of their values after decoding.
+// The monitor rejects the whole reload and keeps the current config if Validate returns an error.
+type Validator interface {
+	Validate() error
+}
+
+var (
+	_ Validator = &ClientFileConfig{}
+	_ Validator = &ServerFileConfig{}
+)
+
+// Validate checks the values of all the categories, the returned error is ValidationErrors.
+// The paths of the errors are relative to the config, e.g. $.retry.Echo.type
+func (c *ClientFileConfig) Validate() error {
+	v := &validator{}
+	for method, t := range c.Timeout {
+		path := JoinPath(JoinPath("", "timeout"), method)
+		validateMethodKey(v, path, method)
+		validateTimeout(v, path, t)
+	}
+	for method, p := range c.Retry {
+		path := JoinPath(JoinPath("", "retry"), method)
+		validateMethodKey(v, path, method)
+		validateRetry(v, path, p)
+	}
+	for method, cb := range c.Circuitbreaker {
+		path := JoinPath(JoinPath("", "circuitbreaker"), method)
+		validateMethodKey(v, path, method)
+		validateCircuitBreaker(v, path, cb)
+	}
+	return v.err()
+}
+
+// Validate checks the values of the limiter, the returned error is ValidationErrors.
+func (c *ServerFileConfig) Validate() error {
+	v := &validator{}
+	path := JoinPath("", "limit")
+	v.check(c.Limit.ConnectionLimit >= 0, JoinPath(path, "connection_limit"), "must not be negative, got %d", c.Limit.ConnectionLimit)
+	v.check(c.Limit.QPSLimit >= 0, JoinPath(path, "qps_limit"), "must not be negative, got %d", c.Limit.QPSLimit)
+	return v.err()
+}
+
+func validateMethodKey(v *validator, path, key string) {
+	if !IsMethodPattern(key) {
+		return
+	}
+	if _, err := compileMethodPattern(key); err != nil {
+		v.check(false, path, "invalid method pattern: %v", err)
+	}
+}
+
+func validateTimeout(v *validator, path string, t *rpctimeout.RPCTimeout) {
+	if !v.check(t != nil, path, "must not be null") {
+		return
+	}
+	v.check(t.RPCTimeoutMS >= 0, JoinPath(path, "rpc_timeout_ms"), "must not be negative, got %d", t.RPCTimeoutMS)
+	v.check(t.ConnTimeoutMS >= 0, JoinPath(path, "conn_timeout_ms"), "must not be negative, got %d", t.ConnTimeoutMS)
+}
+
+func validateRetry(v *validator, path string, p *retry.Policy) {
+	if !v.check(p != nil, path, "must not be null") || !p.Enable {
+		return // a disabled policy is not used by kitex
+	}
+	if !v.check(p.FailurePolicy == nil || p.BackupPolicy == nil, path, "failure_policy and backup_policy must not be set at the same time") {
+		return
+	}
+	if !v.check(p.FailurePolicy != nil || p.BackupPolicy != nil, path, "one of failure_policy and backup_policy must be set") {
+		return
+	}
+
+	switch p.Type {
+	case retry.FailureType:
+		if !v.check(p.FailurePolicy != nil, JoinPath(path, "type"), "type %d requires failure_policy", p.Type) {
+			return
+		}
+		fp := JoinPath(path, "failure_policy")
+		validateStopPolicy(v, JoinPath(fp, "stop_policy"), &p.FailurePolicy.StopPolicy, maxFailureRetryTimes)
+		validateBackOff(v, JoinPath(fp, "backoff_policy"), p.FailurePolicy.BackOffPolicy)
+	case retry.BackupType:
+		if !v.check(p.BackupPolicy != nil, JoinPath(path, "type"), "type %d requires backup_policy", p.Type) {
+			return
+		}
+		bp := JoinPath(path, "backup_policy")
+		v.check(p.BackupPolicy.RetryDelayMS > 0, JoinPath(bp, "retry_delay_ms"), "must be positive")
+		validateStopPolicy(v, JoinPath(bp, "stop_policy"), &p.BackupPolicy.StopPolicy, maxBackupRetryTimes)
+	}
+}
+
+func validateStopPolicy(v *validator, path string, p *retry.StopPolicy, maxRetryTimes int) {
+	v.check(p.MaxRetryTimes >= 0 && p.MaxRetryTimes <= maxRetryTimes, JoinPath(path, "max_retry_times"),
+		"must be between 0 and %d, got %d", maxRetryTimes, p.MaxRetryTimes)
+	// zero error rate means the default one of kitex
+	v.check(p.CBPolicy.ErrorRate >= 0 && p.CBPolicy.ErrorRate <= maxRetryErrorRate, JoinPath(JoinPath(path, "cb_policy"), "error_rate"),
+		"must be between 0 and %v, got %v", maxRetryErrorRate, p.CBPolicy.ErrorRate)
+}
+
+func validateBackOff(v *validator, path string, p *retry.BackOffPolicy) {
+	if p == nil {
+		return
+	}
+	items := JoinPath(path, "cfg_items")
+	for key, value := range p.CfgItems {
+		v.check(value >= 0, JoinPath(items, string(key)), "must not be negative, got %v", value)
+	}
+	switch p.BackOffType {
+	case retry.FixedBackOffType:
+		v.check(p.CfgItems[retry.FixMSBackOffCfgKey] > 0, JoinPath(items, string(retry.FixMSBackOffCfgKey)), "must be positive for fixed backoff")
+	case retry.RandomBackOffType:
+		minMS, maxMS := p.CfgItems[retry.MinMSBackOffCfgKey], p.CfgItems[retry.MaxMSBackOffCfgKey]
+		v.check(maxMS > minMS, JoinPath(items, string(retry.MaxMSBackOffCfgKey)), "must be greater than min_ms %v, got %v", minMS, maxMS)
+	}
+}
+
+func validateCircuitBreaker(v *validator, path string, cb *circuitbreak.CBConfig) {
+	if !v.check(cb != nil, path, "must not be null") {
+		return
+	}
+	v.check(cb.ErrRate >= 0 && cb.ErrRate <= 1, JoinPath(path, "err_rate"), "must be between 0 and 1, got %v", cb.ErrRate)
+	v.check(cb.MinSample >= 0, JoinPath(path, "min_sample"), "must not be negative, got %d", cb.MinSample)
+	if cb.Enable {
+		v.check(cb.ErrRate > 0, JoinPath(path, "err_rate"), "must be positive when enabled")
+		v.check(cb.MinSample > 0, JoinPath(path, "min_sample"), "must be positive when enabled")
+	}
+}
+
+// validator collects all the errors found in a config.
+type validator struct {
+	errs ValidationErrors
+}
+
+// check records an error at path if ok is false, and returns ok.
+func (v *validator) check(ok bool, path, format string, args ...interface{}) bool {
+	if !ok {
+		v.errs = append(v.errs, &FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	return ok
+}
+
+// err returns the collected errors sorted by path, or nil.
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.errs, func(i, j int) bool { return v.errs[i].Path < v.errs[j].Path })
+	return v.errs
+}
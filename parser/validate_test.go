@@ -0,0 +1,52 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	manager := ClientFileManager{}
+	err := DefaultConfigParser().Decode(JSON, []byte(`{
+		"ClientName/ServiceName": {
+			"timeout": {"*": {"rpc_timeout_ms": -1}},
+			"retry": {"*": {"enable": true, "type": 0, "failure_policy": {"stop_policy": {"max_retry_times": 1000}}}},
+			"circuitbreaker": {"Echo": {"enable": true, "err_rate": 3, "min_sample": 100}}
+		}
+	}`), &manager)
+	assert.Nil(t, err)
+
+	errs, ok := manager["ClientName/ServiceName"].Validate().(ValidationErrors)
+	assert.True(t, ok)
+	assert.Equal(t, []string{
+		`$.circuitbreaker.Echo.err_rate: must be between 0 and 1, got 3`,
+		`$.retry["*"].failure_policy.stop_policy.max_retry_times: must be between 0 and 5, got 1000`,
+		`$.timeout["*"].rpc_timeout_ms: must not be negative, got -1`,
+	}, []string{errs[0].Error(), errs[1].Error(), errs[2].Error()})
+
+	// a disabled policy needs neither failure_policy nor backup_policy
+	manager = ClientFileManager{}
+	assert.Nil(t, DefaultConfigParser().Decode(JSON, []byte(`{"Client": {"retry": {"Pay": {"enable": false}}}}`), &manager))
+	assert.Nil(t, manager["Client"].Validate())
+
+	server := &ServerFileConfig{}
+	server.Limit.QPSLimit = -1
+	assert.NotNil(t, server.Validate())
+	server.Limit.QPSLimit = 100
+	assert.Nil(t, server.Validate())
+}
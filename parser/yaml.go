@@ -0,0 +1,108 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"sigs.k8s.io/yaml"
+)
+
+// splitYAMLDocuments splits a multi-document yaml stream by the "---" separator lines.
+// The separators always start at the first column, so they can not appear inside a value.
+func splitYAMLDocuments(data []byte) [][]byte {
+	var docs [][]byte
+	start := 0
+	for offset := 0; offset < len(data); {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += offset + 1
+		}
+		if isYAMLSeparator(bytes.TrimRight(data[offset:end], "\r\n")) {
+			docs = append(docs, data[start:offset])
+			start = end
+		}
+		offset = end
+	}
+	return append(docs, data[start:])
+}
+
+func isYAMLSeparator(line []byte) bool {
+	if !bytes.HasPrefix(line, []byte("---")) {
+		return false
+	}
+	rest := line[3:]
+	return len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t'
+}
+
+// unmarshalYAMLDocuments decodes all the documents of data into a generic document,
+// the top-level keys of the documents are merged and must not repeat, except for VersionKey with the same value.
+func unmarshalYAMLDocuments(data []byte) (interface{}, error) {
+	parts := splitYAMLDocuments(data)
+	if len(parts) == 1 {
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, syntaxError(YAML, data, 1, err)
+		}
+		return doc, nil
+	}
+
+	var merged map[string]interface{}
+	seen := map[string]int{} // key -> index of the document, from 1
+	line := 1                // the first line of the current document
+	for i, part := range parts {
+		var doc interface{}
+		if err := yaml.Unmarshal(part, &doc); err != nil {
+			return nil, syntaxError(YAML, data, line, fmt.Errorf("yaml document %d: %w", i+1, err))
+		}
+		line += bytes.Count(part, []byte("\n")) + 1 // and the separator
+		if doc == nil {
+			continue // empty document, e.g. before the leading separator
+		}
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("yaml document %d: must be a mapping, got %s", i+1, describe(doc))
+		}
+		if merged == nil {
+			merged = make(map[string]interface{}, len(m))
+		}
+		var errs ValidationErrors
+		for key, value := range m {
+			if prev, ok := seen[key]; ok {
+				if key == VersionKey && reflect.DeepEqual(merged[key], value) {
+					continue
+				}
+				errs = append(errs, &FieldError{
+					Path:    JoinPath("", key),
+					Message: fmt.Sprintf("duplicate key in yaml documents %d and %d", prev, i+1),
+				})
+				continue
+			}
+			seen[key] = i + 1
+			merged[key] = value
+		}
+		if len(errs) > 0 {
+			return nil, (&validator{errs: errs}).err()
+		}
+	}
+	if merged == nil {
+		return nil, nil
+	}
+	return merged, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiDocumentYAML(t *testing.T) {
+	data := []byte(`---
+version: 1
+OrderService:
+  limit:
+    qps_limit: 100
+---
+# generated for PaymentService
+version: 1
+PaymentService:
+  limit:
+    connection_limit: 10
+    qps_limit: 200
+---
+`)
+	manager := ServerFileManager{}
+	assert.Nil(t, DefaultConfigParser().Decode(YAML, data, &manager))
+	assert.Len(t, manager, 2)
+	assert.Equal(t, int64(100), manager["OrderService"].Limit.QPSLimit)
+	assert.Equal(t, int64(200), manager["PaymentService"].Limit.QPSLimit)
+
+	duplicate := []byte("OrderService: {}\n--- \nPaymentService: {}\n---\nOrderService: {}\n")
+	err := DefaultConfigParser().Decode(YAML, duplicate, &ServerFileManager{})
+	assert.Equal(t, "$.OrderService: duplicate key in yaml documents 1 and 3", err.Error())
+
+	err = DefaultConfigParser().Decode(YAML, []byte("OrderService: {}\n---\n- PaymentService\n"), &ServerFileManager{})
+	assert.Equal(t, "yaml document 2: must be a mapping, got array", err.Error())
+}
+
+func TestSplitYAMLDocuments(t *testing.T) {
+	docs := splitYAMLDocuments([]byte("a: 1\n---\nb: |\n  ---\n---- c\n--- # d\r\ne: 2"))
+	assert.Equal(t, []string{"a: 1\n", "b: |\n  ---\n---- c\n", "e: 2"}, toStrings(docs))
+}
+
+func toStrings(docs [][]byte) []string {
+	out := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, string(doc))
+	}
+	return out
+}
@@ -63,7 +63,7 @@ func initLimitOptions(watcher monitor.ConfigMonitor) (*limit.Option, int64) {
 		}
 	}
 
-	keyLimiter := watcher.RegisterCallback(onChangeCallback)
+	keyLimiter := watcher.RegisterCallbackFor("limit", onChangeCallback)
 
 	return opt, keyLimiter
 }
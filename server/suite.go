@@ -32,6 +32,8 @@ func NewSuite(key string, watcher filewatcher.FileWatcher, opts ...utils.Option)
 	if err != nil {
 		panic(err)
 	}
+	cm.SetManager(&parser.ServerFileManager{})
+	monitor.Register(cm)
 
 	return &FileConfigServerSuite{
 		watcher: cm,
@@ -40,8 +42,6 @@ func NewSuite(key string, watcher filewatcher.FileWatcher, opts ...utils.Option)
 
 // Options return a list client.Option
 func (s *FileConfigServerSuite) Options() []kitexserver.Option {
-	s.watcher.SetManager(&parser.ServerFileManager{})
-
 	opts := make([]kitexserver.Option, 0, 1)
 	opts = append(opts, WithLimiter(s.watcher))
 
@@ -53,3 +53,14 @@ func (s *FileConfigServerSuite) Options() []kitexserver.Option {
 
 	return opts
 }
+
+// Monitor returns the monitor of the config, e.g. for History and Rollback.
+func (s *FileConfigServerSuite) Monitor() monitor.ConfigMonitor {
+	return s.watcher
+}
+
+// DryRun reports the limits that data, the content of the file, would apply to the server, diffed against the current ones.
+// The limiter is not changed.
+func (s *FileConfigServerSuite) DryRun(data []byte) (*monitor.DryRunResult, error) {
+	return s.watcher.DryRun(data)
+}
@@ -15,9 +15,12 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"io/fs"
 	"os"
+	"time"
 
 	"github.com/kitex-contrib/config-file/parser"
 )
@@ -25,6 +28,46 @@ import (
 type Options struct {
 	Parser parser.ConfigParser
 	Params *parser.ConfigParam
+	Strict bool // reject the config file if it contains unknown fields
+	// Template renders the config file as a Go template before decoding, see parser.TemplateParser.
+	Template bool
+	// Methods of the service, against which the client expands the method patterns in the config, e.g. "Get*".
+	// Methods not listed are learned from the calls.
+	Methods []string
+	// History is the number of the applied config revisions kept for rollback, monitor.DefaultHistorySize if zero.
+	History int
+	// MissingKey is what the monitor does when its key is missing from the file, MissingKeyReset if not set.
+	MissingKey MissingKeyPolicy
+	// Probation watches the calls of the client after each new config, and rolls it back if they regress, nil to disable.
+	Probation *Probation
+}
+
+// MissingKeyPolicy is what the monitor does when its key is missing from the file.
+type MissingKeyPolicy int
+
+const (
+	// MissingKeyReset restores the defaults of kitex: the retry policies are removed, the circuit breakers and timeouts
+	// are reset, and the limits are removed. The last config is kept if the manager provides no defaults.
+	MissingKeyReset MissingKeyPolicy = iota
+	// MissingKeyKeep keeps the last config.
+	MissingKeyKeep
+	// MissingKeyFail rejects the reload as an error, and keeps the last config.
+	MissingKeyFail
+)
+
+// Probation is the policy of the probation of a new client config, whose calls are compared with the ones of the
+// previous config. Zero thresholds are not checked.
+type Probation struct {
+	Window time.Duration // how long a new config is on probation
+	// MinSamples is the number of calls required to compare, of both the previous config and the new one, 100 if zero.
+	// The comparison is repeated every MinSamples calls in the window.
+	MinSamples int
+	// MaxErrorRateIncrease is the max increase of the error rate, e.g. 0.05 from 1% to 6%.
+	MaxErrorRateIncrease float64
+	// MaxLatencyRatio is the max ratio of the p99 latency to the previous one, e.g. 1.5.
+	MaxLatencyRatio float64
+	// OnRollback is called after the config of revision from is rolled back to revision to, with the reason.
+	OnRollback func(from, to int64, reason error)
 }
 
 type Option func(o *Options)
@@ -40,3 +83,9 @@ func PathExists(path string) (bool, error) {
 	}
 	return false, err
 }
+
+// Hash returns the hex sha256 of data, e.g. of the content of a config file.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}